@@ -0,0 +1,43 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// InvalidRequest converts a Gin binding error into a validation error. Struct
+// tag violations are reported per field; malformed bodies keep a single
+// message.
+func InvalidRequest(err error) *Error {
+	e := Validation("Invalid request body or validation failed").Wrap(err)
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return e
+	}
+
+	e.Fields = make(map[string]string, len(validationErrs))
+	for _, fe := range validationErrs {
+		e.Fields[fe.Field()] = describeField(fe)
+	}
+	return e
+}
+
+func describeField(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed the %q rule", fe.Tag())
+	}
+}
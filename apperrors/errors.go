@@ -0,0 +1,96 @@
+// Package apperrors defines the domain error kinds shared by repositories,
+// services and the HTTP error middleware.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sentinel kinds. Every *Error wraps exactly one of these, so callers can
+// branch with errors.Is(err, apperrors.ErrNotFound) regardless of which
+// layer produced the error.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// Error is a domain error with a client-safe message. Fields carries
+// per-field validation messages; Err keeps the underlying cause for logging.
+// RetryAfter, when set, tells the client how long to wait before retrying.
+type Error struct {
+	Kind       error
+	Message    string
+	Fields     map[string]string
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Wrap returns a copy of e that records cause as the underlying error.
+func (e *Error) Wrap(cause error) *Error {
+	clone := *e
+	clone.Err = cause
+	return &clone
+}
+
+// WithField returns a copy of e with an additional field message.
+func (e *Error) WithField(field, message string) *Error {
+	clone := *e
+	clone.Fields = make(map[string]string, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	clone.Fields[field] = message
+	return &clone
+}
+
+// WithRetryAfter returns a copy of e that asks the client to wait d.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	clone := *e
+	clone.RetryAfter = d
+	return &clone
+}
+
+func New(kind error, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func NotFound(format string, args ...any) *Error {
+	return New(ErrNotFound, format, args...)
+}
+
+func Conflict(format string, args ...any) *Error {
+	return New(ErrConflict, format, args...)
+}
+
+func Validation(format string, args ...any) *Error {
+	return New(ErrValidation, format, args...)
+}
+
+func Forbidden(format string, args ...any) *Error {
+	return New(ErrForbidden, format, args...)
+}
+
+func Unauthorized(format string, args ...any) *Error {
+	return New(ErrUnauthorized, format, args...)
+}
+
+func RateLimited(format string, args ...any) *Error {
+	return New(ErrRateLimited, format, args...)
+}
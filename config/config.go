@@ -1,84 +1,228 @@
 package config
 
 import (
-	"log"
-	"os"
+	"time"
 )
 
+// Config is the application configuration. Each setting has a default (see
+// Default), may be set in the optional config file under its `config` key
+// path, and may be overridden by the environment variable in its `env` tag.
 type Config struct {
-	SecretKey string
-	DBConfig  DatabaseConfig
-	Server    ServerConfig
+	SecretKey     string              `config:"secret_key" env:"APP_SECRET_KEY"`
+	SetupToken    string              `config:"setup_token" env:"APP_SETUP_TOKEN"`
+	DBConfig      DatabaseConfig      `config:"database"`
+	Server        ServerConfig        `config:"server"`
+	Token         TokenConfig         `config:"token"`
+	CORS          CORSConfig          `config:"cors"`
+	Mail          MailConfig          `config:"mail"`
+	PasswordReset PasswordResetConfig `config:"password_reset"`
+	Storage       StorageConfig       `config:"storage"`
+	ImageGC       ImageGCConfig       `config:"image_gc"`
+	UserPurge     UserPurgeConfig     `config:"user_purge"`
+	LoginGuard    LoginGuardConfig    `config:"login_guard"`
+	Metrics       MetricsConfig       `config:"metrics"`
+	// PermissionCacheTTL is how long role-permission mappings are cached
+	// before they are read from the database again.
+	PermissionCacheTTL time.Duration `config:"permission_cache_ttl" env:"PERMISSION_CACHE_TTL"`
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Name     string
-	SSLMode  string
+	Host        string `config:"host" env:"DB_HOST"`
+	Port        string `config:"port" env:"DB_PORT"`
+	User        string `config:"user" env:"DB_USER"`
+	Password    string `config:"password" env:"DB_PASSWORD"`
+	Name        string `config:"name" env:"DB_NAME"`
+	SSLMode     string `config:"ssl_mode" env:"DB_SSL_MODE"`
+	AutoMigrate bool   `config:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+	// Connection pool limits; 0 leaves the database/sql default
+	// (unlimited open connections, no lifetime limits).
+	MaxOpenConns    int           `config:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `config:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `config:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
 }
 
 type ServerConfig struct {
-	Port    string
-	Mode    string
-	BaseURL string
+	Port    string `config:"port" env:"APP_SERVER_PORT"`
+	Mode    string `config:"mode" env:"APP_SERVER_MODE"`
+	BaseURL string `config:"base_url" env:"APP_BASE_URL"`
+	// TrustedProxies lists the proxy IPs or CIDRs whose X-Forwarded-For
+	// header is believed. When empty, the client IP is the peer address;
+	// set it when running behind a reverse proxy, or every client shares
+	// the proxy's login rate limit.
+	TrustedProxies []string `config:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	// HealthCheckTimeout bounds each readiness check.
+	HealthCheckTimeout time.Duration `config:"health_check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+	// DrainDelay is how long /readyz reports not-ready before the server
+	// stops accepting connections, giving load balancers time to notice.
+	// It defaults to 0 in debug mode.
+	DrainDelay time.Duration `config:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	// ShutdownTimeout bounds how long in-flight requests may finish once
+	// the server stops accepting connections.
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
 }
 
-func LoadConfig() *Config {
-	var cfg Config
+// TokenConfig sets the lifetimes of issued access and refresh tokens and how
+// often expired ones are purged. A PurgeInterval of 0 disables the purge job.
+type TokenConfig struct {
+	AccessTTL     time.Duration `config:"access_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTTL    time.Duration `config:"refresh_ttl" env:"REFRESH_TOKEN_TTL"`
+	PurgeInterval time.Duration `config:"purge_interval" env:"TOKEN_PURGE_INTERVAL"`
+}
 
-	cfg.SecretKey = os.Getenv("APP_SECRET_KEY")
-	if cfg.SecretKey == "" {
-		log.Fatalf("Error: Required environment variable APP_SECRET_KEY is not set. Application cannot start.")
-	}
+// CORSConfig is the cross-origin policy for browser clients. AllowOrigins
+// takes exact origins such as "https://app.example.com", subdomain patterns
+// such as "https://*.example.com", or a lone "*" for any origin, which
+// cannot be combined with AllowCredentials. ExposeHeaders adds to the
+// headers the API always exposes.
+type CORSConfig struct {
+	AllowOrigins     []string      `config:"allow_origins" env:"CORS_ALLOW_ORIGINS"`
+	AllowMethods     []string      `config:"allow_methods" env:"CORS_ALLOW_METHODS"`
+	AllowHeaders     []string      `config:"allow_headers" env:"CORS_ALLOW_HEADERS"`
+	ExposeHeaders    []string      `config:"expose_headers" env:"CORS_EXPOSE_HEADERS"`
+	AllowCredentials bool          `config:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `config:"max_age" env:"CORS_MAX_AGE"`
+}
 
-	cfg.DBConfig.Host = os.Getenv("DB_HOST")
-	if cfg.DBConfig.Host == "" {
-		log.Fatalf("Error: Required environment variable DB_HOST is not set. Application cannot start.")
-	}
+// MailConfig selects how mail is sent. Driver "log" writes messages to the
+// application log for local development; release mode refuses it unless it
+// is set explicitly.
+type MailConfig struct {
+	Driver       string `config:"driver" env:"MAIL_DRIVER"`
+	From         string `config:"from" env:"MAIL_FROM"`
+	SMTPHost     string `config:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `config:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `config:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `config:"smtp_password" env:"SMTP_PASSWORD"`
+	LogDir       string `config:"log_dir" env:"MAIL_LOG_DIR"`
+}
 
-	cfg.DBConfig.Port = os.Getenv("DB_PORT")
-	if cfg.DBConfig.Port == "" {
-		log.Fatalf("Error: Required environment variable DB_PORT is not set. Application cannot start.")
-	}
+type PasswordResetConfig struct {
+	// URL defaults to <base_url>/reset-password.
+	URL string        `config:"url" env:"PASSWORD_RESET_URL"`
+	TTL time.Duration `config:"ttl" env:"PASSWORD_RESET_TTL"`
+}
 
-	cfg.DBConfig.User = os.Getenv("DB_USER")
-	if cfg.DBConfig.User == "" {
-		log.Fatalf("Error: Required environment variable DB_USER is not set. Application cannot start.")
-	}
+type StorageConfig struct {
+	Driver string `config:"driver" env:"STORAGE_DRIVER"`
+	// LocalDir is the upload directory of the local driver.
+	LocalDir string   `config:"local_dir" env:"STORAGE_LOCAL_DIR"`
+	S3       S3Config `config:"s3"`
+}
 
-	cfg.DBConfig.Password = os.Getenv("DB_PASSWORD")
-	if cfg.DBConfig.Password == "" {
-		log.Fatalf("Error: Required environment variable DB_PASSWORD is not set. Application cannot start.")
-	}
+type S3Config struct {
+	Endpoint  string `config:"endpoint" env:"S3_ENDPOINT"`
+	Region    string `config:"region" env:"S3_REGION"`
+	Bucket    string `config:"bucket" env:"S3_BUCKET"`
+	AccessKey string `config:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey string `config:"secret_key" env:"S3_SECRET_KEY"`
+	UseSSL    bool   `config:"use_ssl" env:"S3_USE_SSL"`
+	// PublicURL serves objects straight from the bucket or a CDN. When empty,
+	// files are proxied through the API's /uploads route.
+	PublicURL string `config:"public_url" env:"S3_PUBLIC_URL"`
+}
 
-	cfg.DBConfig.Name = os.Getenv("DB_NAME")
-	if cfg.DBConfig.Name == "" {
-		log.Fatalf("Error: Required environment variable DB_NAME is not set. Application cannot start.")
-	}
+// ImageGCConfig schedules the sweep of unreferenced profile images. An
+// Interval of 0 disables the periodic job.
+type ImageGCConfig struct {
+	Interval    time.Duration `config:"interval" env:"IMAGE_GC_INTERVAL"`
+	GracePeriod time.Duration `config:"grace_period" env:"IMAGE_GC_GRACE_PERIOD"`
+}
 
-	cfg.DBConfig.SSLMode = os.Getenv("DB_SSL_MODE")
-	if cfg.DBConfig.SSLMode == "" {
-		log.Fatalf("Error: Required environment variable DB_SSL_MODE is not set. Application cannot start.")
-	}
+// UserPurgeConfig controls how long soft-deleted users are kept before they
+// are removed for good. An Interval of 0 disables the purge job.
+type UserPurgeConfig struct {
+	Interval  time.Duration `config:"interval" env:"USER_PURGE_INTERVAL"`
+	Retention time.Duration `config:"retention" env:"USER_PURGE_RETENTION"`
+}
 
-	cfg.Server.Port = os.Getenv("APP_SERVER_PORT")
-	if cfg.Server.Port == "" {
-		log.Fatalf("Error: Required environment variable APP_SERVER_PORT is not set. Application cannot start.")
-	}
+// LoginGuardConfig throttles failed logins. Store is "memory" for a single
+// instance, "redis" for clusters, or "miniredis" to run the Redis store on
+// an embedded stand-in server.
+type LoginGuardConfig struct {
+	Store         string        `config:"store" env:"LOGIN_GUARD_STORE"`
+	RedisURL      string        `config:"redis_url" env:"REDIS_URL"`
+	MaxFailures   int           `config:"max_failures" env:"LOGIN_MAX_FAILURES"`
+	Window        time.Duration `config:"failure_window" env:"LOGIN_FAILURE_WINDOW"`
+	Lockout       time.Duration `config:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION"`
+	IPMaxFailures int           `config:"ip_max_failures" env:"LOGIN_IP_MAX_FAILURES"`
+	BackoffBase   time.Duration `config:"backoff_base" env:"LOGIN_BACKOFF_BASE"`
+	BackoffMax    time.Duration `config:"backoff_max" env:"LOGIN_BACKOFF_MAX"`
+}
 
-	cfg.Server.Mode = os.Getenv("APP_SERVER_MODE")
-	if cfg.Server.Mode == "" {
-		log.Fatalf("Error: Required environment variable APP_SERVER_MODE is not set. Application cannot start.")
-	}
+// MetricsConfig exposes the Prometheus endpoint. With Addr set, /metrics is
+// served on that separate listener only; otherwise it is mounted on the API
+// when Token is set. Token, when set, is always required as a bearer token.
+// With neither, metrics are disabled.
+type MetricsConfig struct {
+	Addr  string `config:"addr" env:"METRICS_ADDR"`
+	Token string `config:"token" env:"METRICS_TOKEN"`
+}
 
-	cfg.Server.BaseURL = os.Getenv("APP_BASE_URL")
-	if cfg.Server.BaseURL == "" {
-		log.Fatalf("Error: Required environment variable APP_BASE_URL is not set. Application cannot start.")
-	}
+// Enabled reports whether the metrics endpoint is exposed anywhere.
+func (c MetricsConfig) Enabled() bool {
+	return c.Addr != "" || c.Token != ""
+}
 
-	log.Println("Configuration loaded successfully from environment variables.")
-	return &cfg
+// Default returns the configuration used for every setting that neither the
+// config file nor the environment sets.
+func Default() Config {
+	return Config{
+		DBConfig: DatabaseConfig{
+			Port:            "5432",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Server: ServerConfig{
+			Port:               "8080",
+			Mode:               "release",
+			HealthCheckTimeout: 2 * time.Second,
+			DrainDelay:         5 * time.Second,
+			ShutdownTimeout:    10 * time.Second,
+		},
+		Token: TokenConfig{
+			AccessTTL:     15 * time.Minute,
+			RefreshTTL:    30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"*"},
+			AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowHeaders: []string{"Content-Type", "Authorization", "X-Request-ID"},
+			MaxAge:       12 * time.Hour,
+		},
+		Mail: MailConfig{
+			Driver:   "log",
+			From:     "no-reply@localhost",
+			SMTPPort: "587",
+		},
+		PasswordReset: PasswordResetConfig{
+			TTL: time.Hour,
+		},
+		Storage: StorageConfig{
+			Driver:   "local",
+			LocalDir: "./uploads",
+			S3:       S3Config{UseSSL: true},
+		},
+		ImageGC: ImageGCConfig{
+			Interval:    24 * time.Hour,
+			GracePeriod: time.Hour,
+		},
+		UserPurge: UserPurgeConfig{
+			Interval:  24 * time.Hour,
+			Retention: 30 * 24 * time.Hour,
+		},
+		LoginGuard: LoginGuardConfig{
+			Store:         "memory",
+			MaxFailures:   5,
+			Window:        15 * time.Minute,
+			Lockout:       15 * time.Minute,
+			IPMaxFailures: 20,
+			BackoffBase:   time.Second,
+			BackoffMax:    time.Minute,
+		},
+		PermissionCacheTTL: time.Minute,
+	}
 }
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable holding the optional config file.
+const FileEnv = "APP_CONFIG_FILE"
+
+// ValidationError lists every problem found while loading the configuration,
+// so a deployment can be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	noun := "problems"
+	if len(e.Problems) == 1 {
+		noun = "problem"
+	}
+	return fmt.Sprintf("invalid configuration (%d %s):\n  - %s", len(e.Problems), noun, strings.Join(e.Problems, "\n  - "))
+}
+
+// Load reads the configuration file named by APP_CONFIG_FILE, if any. See
+// LoadFile.
+func Load() (*Config, error) {
+	return LoadFile(os.Getenv(FileEnv))
+}
+
+// LoadFile starts from Default, applies the YAML or TOML file at path (when
+// not empty) and then the environment variables, which take precedence.
+// Empty variables count as unset. Unknown file keys, unparsable values and
+// failed validations are all collected into one *ValidationError.
+func LoadFile(path string) (*Config, error) {
+	var file map[string]any
+	if path != "" {
+		var err error
+		if file, err = readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := Default()
+	l := &loader{set: make(map[string]bool), envs: make(map[string]string)}
+	l.apply(reflect.ValueOf(&cfg).Elem(), file, "")
+
+	if cfg.PasswordReset.URL == "" {
+		cfg.PasswordReset.URL = strings.TrimSuffix(cfg.Server.BaseURL, "/") + "/reset-password"
+	}
+	if !l.set["server.drain_delay"] && cfg.Server.Mode == "debug" {
+		cfg.Server.DrainDelay = 0
+	}
+
+	l.validate(&cfg)
+	if len(l.problems) > 0 {
+		return nil, &ValidationError{Problems: l.problems}
+	}
+	return &cfg, nil
+}
+
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	file := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("config file %s must have a .yaml, .yml or .toml extension", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// loader copies file and environment values into the tagged fields of
+// Config, recording which keys were set and every problem it meets.
+type loader struct {
+	set      map[string]bool
+	envs     map[string]string
+	problems []string
+}
+
+func (l *loader) problemf(format string, args ...any) {
+	l.problems = append(l.problems, fmt.Sprintf(format, args...))
+}
+
+// label names a setting by its file key and environment variable.
+func (l *loader) label(key string) string {
+	if env := l.envs[key]; env != "" {
+		return fmt.Sprintf("%s (%s)", key, env)
+	}
+	return key
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func (l *loader) apply(v reflect.Value, file map[string]any, prefix string) {
+	t := v.Type()
+	known := make(map[string]bool, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("config")
+		if name == "" {
+			continue
+		}
+		known[name] = true
+		key := prefix + name
+		raw, inFile := file[name]
+
+		if field.Type.Kind() == reflect.Struct {
+			section, ok := raw.(map[string]any)
+			if inFile && !ok {
+				l.problemf("config file key %s must be a table of settings", key)
+			}
+			l.apply(v.Field(i), section, key+".")
+			continue
+		}
+
+		env := field.Tag.Get("env")
+		l.envs[key] = env
+		if inFile {
+			if err := setValue(v.Field(i), raw); err != nil {
+				l.problemf("config file key %s: %v", key, err)
+			}
+			l.set[key] = true
+		}
+		if value := os.Getenv(env); env != "" && value != "" {
+			if err := setValue(v.Field(i), value); err != nil {
+				l.problemf("environment variable %s: %v", env, err)
+			}
+			l.set[key] = true
+		}
+	}
+
+	var unknown []string
+	for name := range file {
+		if !known[name] {
+			unknown = append(unknown, prefix+name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		l.problemf("config file key %s is not a known setting", key)
+	}
+}
+
+// setValue stores raw, a string from the environment or a decoded file
+// value, in field.
+func setValue(field reflect.Value, raw any) error {
+	if field.Type() == durationType {
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("must be a duration such as \"30s\" or \"15m\", got %v", raw)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		switch raw.(type) {
+		case map[string]any, []any:
+			return fmt.Errorf("must be a single value")
+		}
+		field.SetString(fmt.Sprint(raw))
+	case reflect.Int:
+		n, err := toInt(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		switch b := raw.(type) {
+		case bool:
+			field.SetBool(b)
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", b)
+			}
+			field.SetBool(parsed)
+		default:
+			return fmt.Errorf("must be true or false, got %v", raw)
+		}
+	case reflect.Slice:
+		list, err := toList(raw)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+func toInt(raw any) (int64, error) {
+	switch n := raw.(type) {
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint64:
+		return int64(n), nil
+	case float64:
+		if n == float64(int64(n)) {
+			return int64(n), nil
+		}
+	case string:
+		parsed, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer %q", n)
+		}
+		return int64(parsed), nil
+	}
+	return 0, fmt.Errorf("must be an integer, got %v", raw)
+}
+
+// toList accepts a list of values or a comma-separated string, dropping
+// empty items.
+func toList(raw any) ([]string, error) {
+	var items []string
+	switch list := raw.(type) {
+	case string:
+		for _, item := range strings.Split(list, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	case []any:
+		for _, item := range list {
+			switch item.(type) {
+			case map[string]any, []any:
+				return nil, fmt.Errorf("must be a list of values")
+			}
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+				items = append(items, s)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("must be a list or a comma-separated string, got %v", raw)
+	}
+	return items, nil
+}
@@ -0,0 +1,364 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const validYAML = `
+secret_key: file-secret
+database:
+  host: db.internal
+  user: app
+  password: hunter2
+  name: education
+  ssl_mode: require
+server:
+  port: "9000"
+  base_url: https://api.example.com
+mail:
+  driver: log
+`
+
+const validTOML = `
+secret_key = "file-secret"
+
+[database]
+host = "db.internal"
+user = "app"
+password = "hunter2"
+name = "education"
+ssl_mode = "require"
+
+[server]
+port = "9000"
+base_url = "https://api.example.com"
+
+[mail]
+driver = "log"
+`
+
+// clearEnv unsets every variable Config reads, so the environment of the
+// test process cannot leak into a case. Empty variables count as unset.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	var walk func(reflect.Type)
+	walk = func(typ reflect.Type) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+				walk(field.Type)
+			} else if env := field.Tag.Get("env"); env != "" {
+				t.Setenv(env, "")
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}))
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+// loadProblems loads path and returns the reported problems, failing the
+// test on any other outcome.
+func loadProblems(t *testing.T, path string) []string {
+	t.Helper()
+	_, err := LoadFile(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("LoadFile error = %v, want a *ValidationError", err)
+	}
+	return invalid.Problems
+}
+
+func TestLoadFilePrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "config.yaml", validYAML},
+		{"yml", "config.yml", validYAML},
+		{"toml", "config.toml", validTOML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("APP_SERVER_PORT", "9100")
+			t.Setenv("DB_PASSWORD", "from-env")
+
+			cfg, err := LoadFile(writeConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+
+			if cfg.Server.Port != "9100" {
+				t.Errorf("server.port = %q, want the environment's 9100", cfg.Server.Port)
+			}
+			if cfg.DBConfig.Password != "from-env" {
+				t.Errorf("database.password = %q, want the environment's value", cfg.DBConfig.Password)
+			}
+			if cfg.DBConfig.Host != "db.internal" {
+				t.Errorf("database.host = %q, want the file's db.internal", cfg.DBConfig.Host)
+			}
+			if cfg.Token.AccessTTL != Default().Token.AccessTTL {
+				t.Errorf("token.access_ttl = %s, want the default %s", cfg.Token.AccessTTL, Default().Token.AccessTTL)
+			}
+			if cfg.PasswordReset.URL != "https://api.example.com/reset-password" {
+				t.Errorf("password_reset.url = %q, want it derived from server.base_url", cfg.PasswordReset.URL)
+			}
+		})
+	}
+}
+
+func TestLoadFileEnvOnly(t *testing.T) {
+	clearEnv(t)
+	for env, value := range map[string]string{
+		"APP_SECRET_KEY": "env-secret",
+		"DB_HOST":        "localhost",
+		"DB_USER":        "app",
+		"DB_PASSWORD":    "hunter2",
+		"DB_NAME":        "education",
+		"DB_SSL_MODE":    "disable",
+		"APP_BASE_URL":   "http://localhost:8080",
+		"MAIL_DRIVER":    "log",
+	} {
+		t.Setenv(env, value)
+	}
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.SecretKey != "env-secret" || cfg.Server.Port != "8080" {
+		t.Errorf("got secret_key %q and server.port %q, want env-secret and the default 8080", cfg.SecretKey, cfg.Server.Port)
+	}
+}
+
+func TestLoadFileUnknownKeys(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "config.yaml", validYAML+`
+servr:
+  port: "1"
+token:
+  acess_ttl: 5m
+`)
+
+	problems := loadProblems(t, path)
+	want := []string{
+		"config file key servr is not a known setting",
+		"config file key token.acess_ttl is not a known setting",
+	}
+	for _, problem := range want {
+		if !slices.Contains(problems, problem) {
+			t.Errorf("problems %q do not include %q", problems, problem)
+		}
+	}
+}
+
+func TestLoadFileLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     string
+		want    []string
+	}{
+		{
+			name:    "comma-separated environment variable",
+			content: validYAML,
+			env:     " https://a.example.com, https://b.example.com ,,",
+			want:    []string{"https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:    "file list",
+			content: validYAML + "cors:\n  allow_origins: [https://a.example.com, \"\", https://b.example.com]\n",
+			want:    []string{"https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:    "comma-separated file string",
+			content: validYAML + "cors:\n  allow_origins: https://a.example.com,https://b.example.com\n",
+			want:    []string{"https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:    "environment replaces the file list",
+			content: validYAML + "cors:\n  allow_origins: [https://a.example.com]\n",
+			env:     "https://c.example.com",
+			want:    []string{"https://c.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("CORS_ALLOW_ORIGINS", tt.env)
+
+			cfg, err := LoadFile(writeConfig(t, "config.yaml", tt.content))
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if !slices.Equal(cfg.CORS.AllowOrigins, tt.want) {
+				t.Errorf("cors.allow_origins = %q, want %q", cfg.CORS.AllowOrigins, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileDurations(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		env         string
+		want        time.Duration
+		wantProblem string
+	}{
+		{name: "file", content: validYAML + "token:\n  access_ttl: 5m\n", want: 5 * time.Minute},
+		{name: "environment", content: validYAML + "token:\n  access_ttl: 5m\n", env: "90s", want: 90 * time.Second},
+		{name: "compound", content: validYAML, env: "1h30m", want: 90 * time.Minute},
+		{
+			name:        "unparsable environment",
+			content:     validYAML,
+			env:         "soon",
+			wantProblem: `environment variable ACCESS_TOKEN_TTL: invalid duration "soon"`,
+		},
+		{
+			name:        "bare number in file",
+			content:     validYAML + "token:\n  access_ttl: 30\n",
+			wantProblem: "config file key token.access_ttl: must be a duration",
+		},
+		{
+			name:        "zero",
+			content:     validYAML,
+			env:         "0s",
+			wantProblem: "token.access_ttl (ACCESS_TOKEN_TTL) must be greater than 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("ACCESS_TOKEN_TTL", tt.env)
+			path := writeConfig(t, "config.yaml", tt.content)
+
+			if tt.wantProblem != "" {
+				problems := loadProblems(t, path)
+				if !slices.ContainsFunc(problems, func(p string) bool { return strings.HasPrefix(p, tt.wantProblem) }) {
+					t.Errorf("problems %q do not include %q", problems, tt.wantProblem)
+				}
+				return
+			}
+
+			cfg, err := LoadFile(path)
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if cfg.Token.AccessTTL != tt.want {
+				t.Errorf("token.access_ttl = %s, want %s", cfg.Token.AccessTTL, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileReportsAllProblems(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("APP_SERVER_PORT", "http")
+	t.Setenv("LOGIN_MAX_FAILURES", "many")
+	t.Setenv("LOGIN_BACKOFF_BASE", "2m")
+	path := writeConfig(t, "config.yaml", `
+database:
+  host: db.internal
+  ssl_mode: sometimes
+server:
+  base_url: api.example.com
+image_gc:
+  grace_period: 10s
+unknown: true
+`)
+
+	_, err := LoadFile(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("LoadFile error = %v, want a *ValidationError", err)
+	}
+
+	want := []string{
+		"environment variable LOGIN_MAX_FAILURES: ",
+		"config file key unknown is not a known setting",
+		"secret_key (APP_SECRET_KEY) is required",
+		"database.user (DB_USER) is required",
+		"database.ssl_mode (DB_SSL_MODE) must be one of ",
+		"server.port (APP_SERVER_PORT) must be a port number",
+		"server.base_url (APP_BASE_URL) must be an absolute http(s) URL",
+		"image_gc.grace_period (IMAGE_GC_GRACE_PERIOD) must be at least 1m",
+		"login_guard.backoff_base (LOGIN_BACKOFF_BASE) must not be greater than login_guard.backoff_max",
+	}
+	for _, prefix := range want {
+		if !slices.ContainsFunc(invalid.Problems, func(p string) bool { return strings.HasPrefix(p, prefix) }) {
+			t.Errorf("problems do not include %q", prefix)
+		}
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "invalid configuration (") || strings.Count(msg, "\n  - ") != len(invalid.Problems) {
+		t.Errorf("error message does not list every problem:\n%s", msg)
+	}
+}
+
+func TestLoadFileRejectsUnknownExtension(t *testing.T) {
+	clearEnv(t)
+	_, err := LoadFile(writeConfig(t, "config.json", "{}"))
+	var invalid *ValidationError
+	if err == nil || errors.As(err, &invalid) {
+		t.Fatalf("LoadFile error = %v, want a file error", err)
+	}
+}
+
+func TestLoadFileMailDriverInRelease(t *testing.T) {
+	const base = `
+secret_key: file-secret
+database:
+  host: db.internal
+  user: app
+  password: hunter2
+  name: education
+  ssl_mode: require
+server:
+  base_url: https://api.example.com
+`
+	tests := []struct {
+		name        string
+		content     string
+		wantProblem bool
+	}{
+		{"release with the default driver", base, true},
+		{"release with log chosen explicitly", base + "mail:\n  driver: log\n", false},
+		{"release with smtp", base + "mail:\n  driver: smtp\n  smtp_host: smtp.example.com\n", false},
+		{"debug with the default driver", strings.Replace(base, "server:\n", "server:\n  mode: debug\n", 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			path := writeConfig(t, "config.yaml", tt.content)
+
+			if !tt.wantProblem {
+				if _, err := LoadFile(path); err != nil {
+					t.Fatalf("LoadFile: %v", err)
+				}
+				return
+			}
+			problems := loadProblems(t, path)
+			if !slices.ContainsFunc(problems, func(p string) bool {
+				return strings.HasPrefix(p, "mail.driver (MAIL_DRIVER) must be set in release mode")
+			}) {
+				t.Errorf("problems %q do not flag the default mail driver", problems)
+			}
+		})
+	}
+}
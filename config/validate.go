@@ -0,0 +1,198 @@
+package config
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validate records a problem for every setting that is missing or out of
+// range, rather than stopping at the first.
+func (l *loader) validate(cfg *Config) {
+	l.required("secret_key", cfg.SecretKey)
+	l.required("database.host", cfg.DBConfig.Host)
+	l.required("database.port", cfg.DBConfig.Port)
+	l.required("database.user", cfg.DBConfig.User)
+	l.required("database.password", cfg.DBConfig.Password)
+	l.required("database.name", cfg.DBConfig.Name)
+	if l.required("database.ssl_mode", cfg.DBConfig.SSLMode) {
+		l.oneOf("database.ssl_mode", cfg.DBConfig.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	}
+	l.nonNegative("database.max_open_conns", cfg.DBConfig.MaxOpenConns)
+	l.nonNegative("database.max_idle_conns", cfg.DBConfig.MaxIdleConns)
+	l.nonNegativeDuration("database.conn_max_lifetime", cfg.DBConfig.ConnMaxLifetime)
+	l.nonNegativeDuration("database.conn_max_idle_time", cfg.DBConfig.ConnMaxIdleTime)
+
+	if l.required("server.port", cfg.Server.Port) {
+		if port, err := strconv.Atoi(cfg.Server.Port); err != nil || port < 1 || port > 65535 {
+			l.problemf("%s must be a port number between 1 and 65535, got %q", l.label("server.port"), cfg.Server.Port)
+		}
+	}
+	l.oneOf("server.mode", cfg.Server.Mode, "debug", "release", "test")
+	if l.required("server.base_url", cfg.Server.BaseURL) {
+		l.absoluteURL("server.base_url", cfg.Server.BaseURL)
+	}
+	l.positiveDuration("server.health_check_timeout", cfg.Server.HealthCheckTimeout)
+	l.nonNegativeDuration("server.drain_delay", cfg.Server.DrainDelay)
+	l.positiveDuration("server.shutdown_timeout", cfg.Server.ShutdownTimeout)
+
+	l.positiveDuration("token.access_ttl", cfg.Token.AccessTTL)
+	l.positiveDuration("token.refresh_ttl", cfg.Token.RefreshTTL)
+	if cfg.Token.RefreshTTL > 0 && cfg.Token.RefreshTTL < cfg.Token.AccessTTL {
+		l.problemf("%s must not be shorter than %s", l.label("token.refresh_ttl"), l.label("token.access_ttl"))
+	}
+	l.nonNegativeDuration("token.purge_interval", cfg.Token.PurgeInterval)
+
+	l.validateCORS(&cfg.CORS)
+
+	if l.oneOf("mail.driver", cfg.Mail.Driver, "smtp", "log") && cfg.Mail.Driver == "smtp" {
+		l.required("mail.smtp_host", cfg.Mail.SMTPHost)
+	}
+	// The log driver writes every message, password reset links included,
+	// to the application log. Release deployments must opt into it.
+	if cfg.Server.Mode == "release" && cfg.Mail.Driver == "log" && !l.set["mail.driver"] {
+		l.problemf("%s must be set in release mode; the default \"log\" driver writes password reset links to the application log", l.label("mail.driver"))
+	}
+
+	if cfg.Server.BaseURL != "" {
+		l.absoluteURL("password_reset.url", cfg.PasswordReset.URL)
+	}
+	l.positiveDuration("password_reset.ttl", cfg.PasswordReset.TTL)
+
+	if l.oneOf("storage.driver", cfg.Storage.Driver, "local", "s3") {
+		switch cfg.Storage.Driver {
+		case "local":
+			l.required("storage.local_dir", cfg.Storage.LocalDir)
+		case "s3":
+			l.required("storage.s3.endpoint", cfg.Storage.S3.Endpoint)
+			l.required("storage.s3.bucket", cfg.Storage.S3.Bucket)
+		}
+	}
+
+	l.nonNegativeDuration("image_gc.interval", cfg.ImageGC.Interval)
+	// A fresh upload is stored before profile_url points at it, so a short
+	// grace period would let the sweep delete images still being saved.
+	if cfg.ImageGC.GracePeriod < time.Minute {
+		l.problemf("%s must be at least 1m, got %s", l.label("image_gc.grace_period"), cfg.ImageGC.GracePeriod)
+	}
+	l.nonNegativeDuration("user_purge.interval", cfg.UserPurge.Interval)
+	l.positiveDuration("user_purge.retention", cfg.UserPurge.Retention)
+
+	if l.oneOf("login_guard.store", cfg.LoginGuard.Store, "memory", "redis", "miniredis") && cfg.LoginGuard.Store == "redis" {
+		l.required("login_guard.redis_url", cfg.LoginGuard.RedisURL)
+	}
+	l.positive("login_guard.max_failures", cfg.LoginGuard.MaxFailures)
+	l.positive("login_guard.ip_max_failures", cfg.LoginGuard.IPMaxFailures)
+	l.positiveDuration("login_guard.failure_window", cfg.LoginGuard.Window)
+	l.positiveDuration("login_guard.lockout_duration", cfg.LoginGuard.Lockout)
+	l.nonNegativeDuration("login_guard.backoff_base", cfg.LoginGuard.BackoffBase)
+	l.nonNegativeDuration("login_guard.backoff_max", cfg.LoginGuard.BackoffMax)
+	if cfg.LoginGuard.BackoffBase > cfg.LoginGuard.BackoffMax {
+		l.problemf("%s must not be greater than %s", l.label("login_guard.backoff_base"), l.label("login_guard.backoff_max"))
+	}
+
+	l.nonNegativeDuration("permission_cache_ttl", cfg.PermissionCacheTTL)
+}
+
+func (l *loader) validateCORS(cors *CORSConfig) {
+	if len(cors.AllowOrigins) == 0 {
+		l.problemf("%s must list at least one origin", l.label("cors.allow_origins"))
+	}
+	for _, origin := range cors.AllowOrigins {
+		if origin == "*" {
+			if len(cors.AllowOrigins) > 1 {
+				l.problemf("%s must not combine \"*\" with other origins", l.label("cors.allow_origins"))
+			}
+			if cors.AllowCredentials {
+				l.problemf("%s cannot be \"*\" while %s is enabled; browsers reject credentials for any origin", l.label("cors.allow_origins"), l.label("cors.allow_credentials"))
+			}
+			continue
+		}
+		if !validOriginPattern(origin) {
+			l.problemf("%s has invalid origin %q; use scheme://host[:port], optionally with a leading \"*.\" subdomain wildcard", l.label("cors.allow_origins"), origin)
+		}
+	}
+
+	if len(cors.AllowMethods) == 0 {
+		l.problemf("%s must list at least one method", l.label("cors.allow_methods"))
+	}
+	for _, method := range cors.AllowMethods {
+		if method != strings.ToUpper(method) || strings.ContainsAny(method, " ,*") {
+			l.problemf("%s has invalid method %q", l.label("cors.allow_methods"), method)
+		}
+	}
+	l.nonNegativeDuration("cors.max_age", cors.MaxAge)
+}
+
+// validOriginPattern accepts an origin without path, or one whose host
+// starts with a "*." wildcard for any subdomain. A wildcard elsewhere, such
+// as "https://*example.com", would also match unrelated domains.
+func validOriginPattern(origin string) bool {
+	if strings.Count(origin, "*") > 1 {
+		return false
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return false
+	}
+	if strings.Contains(host, "*") {
+		rest, ok := strings.CutPrefix(host, "*.")
+		if !ok {
+			return false
+		}
+		host = rest
+	}
+	u, err := url.Parse(scheme + "://" + host)
+	return err == nil && u.Host == host && u.Hostname() != "" && u.User == nil
+}
+
+// required reports whether value is set, recording a problem if not.
+func (l *loader) required(key, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		l.problemf("%s is required", l.label(key))
+		return false
+	}
+	return true
+}
+
+// oneOf reports whether value is one of allowed, recording a problem if not.
+func (l *loader) oneOf(key, value string, allowed ...string) bool {
+	if !slices.Contains(allowed, value) {
+		l.problemf("%s must be one of %s, got %q", l.label(key), strings.Join(allowed, ", "), value)
+		return false
+	}
+	return true
+}
+
+func (l *loader) absoluteURL(key, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		l.problemf("%s must be an absolute http(s) URL, got %q", l.label(key), value)
+	}
+}
+
+func (l *loader) positive(key string, value int) {
+	if value <= 0 {
+		l.problemf("%s must be greater than 0, got %d", l.label(key), value)
+	}
+}
+
+func (l *loader) nonNegative(key string, value int) {
+	if value < 0 {
+		l.problemf("%s must not be negative, got %d", l.label(key), value)
+	}
+}
+
+func (l *loader) positiveDuration(key string, value time.Duration) {
+	if value <= 0 {
+		l.problemf("%s must be greater than 0, got %s", l.label(key), value)
+	}
+}
+
+func (l *loader) nonNegativeDuration(key string, value time.Duration) {
+	if value < 0 {
+		l.problemf("%s must not be negative, got %s", l.label(key), value)
+	}
+}
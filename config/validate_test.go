@@ -0,0 +1,36 @@
+package config
+
+import "testing"
+
+func TestValidOriginPattern(t *testing.T) {
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"http://localhost:3000", true},
+		{"https://*.example.com", true},
+		{"https://*.example.com:8443", true},
+		{"http://127.0.0.1:5173", true},
+		{"https://*example.com", false},
+		{"https://app.*.example.com", false},
+		{"https://example.*", false},
+		{"https://*.*.example.com", false},
+		{"https://*.", false},
+		{"*.example.com", false},
+		{"ftp://example.com", false},
+		{"https://", false},
+		{"https://example.com/", false},
+		{"https://example.com/path", false},
+		{"https://user@example.com", false},
+		{"https://example.com?x=1", false},
+		{"example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := validOriginPattern(tt.origin); got != tt.want {
+				t.Errorf("validOriginPattern(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
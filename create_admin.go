@@ -0,0 +1,73 @@
+package main
+
+import (
+	"be-education/db"
+	"be-education/models"
+	"be-education/repository"
+	"be-education/service"
+	"be-education/storage"
+	"be-education/utils"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// runCreateAdmin implements the "create-admin" subcommand. Flags take
+// precedence over the ADMIN_NAME, ADMIN_EMAIL and ADMIN_PASSWORD variables.
+func runCreateAdmin(args []string) int {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	name := fs.String("name", os.Getenv("ADMIN_NAME"), "admin display name (env ADMIN_NAME)")
+	email := fs.String("email", os.Getenv("ADMIN_EMAIL"), "admin email (env ADMIN_EMAIL)")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "admin password (env ADMIN_PASSWORD)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *name == "" || *email == "" || *password == "" {
+		fmt.Fprintln(os.Stderr, "create-admin requires a name, email and password")
+		fs.Usage()
+		return 2
+	}
+	if len(*password) < 6 {
+		fmt.Fprintln(os.Stderr, "password must be at least 6 characters")
+		return 2
+	}
+
+	cfg, ok := loadConfig()
+	if !ok {
+		return 1
+	}
+
+	dbConn, err := db.Connect(cfg)
+	if err != nil {
+		slog.Error("Failed to connect to the database", "error", err)
+		return 1
+	}
+	defer db.Close(dbConn)
+
+	userRepo := repository.NewUserRepository(dbConn)
+	tokenService := service.NewTokenService(repository.NewTokenRepository(dbConn), userRepo, utils.NewJWTUtil(cfg.SecretKey, cfg.Token.AccessTTL), cfg.Token.RefreshTTL)
+	files, err := storage.New(cfg)
+	if err != nil {
+		slog.Error("Failed to set up file storage", "error", err)
+		return 1
+	}
+	permissionService := service.NewPermissionService(repository.NewPermissionRepository(dbConn), cfg.PermissionCacheTTL)
+	userService := service.NewUserService(userRepo, repository.NewClassRepository(dbConn), tokenService, permissionService, files, nil)
+
+	user := &models.User{
+		Name:     *name,
+		Email:    *email,
+		Password: *password,
+	}
+
+	if err := userService.CreateAdmin(context.Background(), user); err != nil {
+		slog.Error("Failed to create admin", "error", err)
+		return 1
+	}
+
+	fmt.Printf("Created admin %s (ID %d)\n", user.Email, user.ID)
+	return 0
+}
@@ -4,7 +4,7 @@ import (
 	"be-education/config"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -26,6 +26,11 @@ func Connect(cfg *config.Config) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 
+	db.SetMaxOpenConns(cfg.DBConfig.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConfig.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConfig.ConnMaxIdleTime)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -33,7 +38,7 @@ func Connect(cfg *config.Config) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
 
-	log.Println("Successfully connected to the database")
+	slog.Info("Connected to the database")
 	return db, nil
 }
 
@@ -41,6 +46,6 @@ func Close(db *sqlx.DB) error {
 	if err := db.Close(); err != nil {
 		return fmt.Errorf("error closing database: %w", err)
 	}
-	log.Println("Database connection closed")
+	slog.Info("Database connection closed")
 	return nil
 }
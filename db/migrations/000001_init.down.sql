@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_chapters;
+DROP TABLE IF EXISTS chapters;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,35 @@
+-- Baseline schema. IF NOT EXISTS lets environments that were set up by hand
+-- adopt the migrator without recreating their tables.
+CREATE TABLE IF NOT EXISTS users (
+    id          BIGSERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    email       VARCHAR(255) NOT NULL UNIQUE,
+    password    TEXT NOT NULL,
+    class       VARCHAR(100),
+    birthday    DATE,
+    role        VARCHAR(50) NOT NULL DEFAULT 'mahasiswa',
+    profile_url TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS chapters (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_chapters (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chapter_id   BIGINT NOT NULL REFERENCES chapters (id),
+    completed_at TIMESTAMPTZ,
+    quiz_score   DOUBLE PRECISION,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role);
+CREATE INDEX IF NOT EXISTS idx_user_chapters_user_id ON user_chapters (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_chapters_chapter_id ON user_chapters (chapter_id);
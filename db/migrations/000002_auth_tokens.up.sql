@@ -0,0 +1,25 @@
+CREATE TABLE refresh_tokens (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id         UUID NOT NULL,
+    token_hash        CHAR(64) NOT NULL UNIQUE,
+    access_jti        VARCHAR(64) NOT NULL,
+    access_expires_at TIMESTAMPTZ NOT NULL,
+    expires_at        TIMESTAMPTZ NOT NULL,
+    revoked_at        TIMESTAMPTZ,
+    replaced_by_id    BIGINT REFERENCES refresh_tokens (id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX idx_refresh_tokens_access_jti ON refresh_tokens (access_jti);
+
+CREATE TABLE revoked_tokens (
+    jti        VARCHAR(64) PRIMARY KEY,
+    user_id    BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
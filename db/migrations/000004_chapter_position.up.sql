@@ -0,0 +1,8 @@
+ALTER TABLE chapters ADD COLUMN position INT NOT NULL DEFAULT 0;
+
+UPDATE chapters c
+SET position = ordered.rn
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY id) AS rn FROM chapters) ordered
+WHERE c.id = ordered.id;
+
+CREATE INDEX idx_chapters_position ON chapters (position);
@@ -0,0 +1,29 @@
+CREATE TABLE quizzes (
+    id          BIGSERIAL PRIMARY KEY,
+    chapter_id  BIGINT NOT NULL UNIQUE REFERENCES chapters (id) ON DELETE CASCADE,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE quiz_questions (
+    id       BIGSERIAL PRIMARY KEY,
+    quiz_id  BIGINT NOT NULL REFERENCES quizzes (id) ON DELETE CASCADE,
+    type     VARCHAR(32) NOT NULL CHECK (type IN ('single_choice', 'multiple_choice', 'short_answer')),
+    prompt   TEXT NOT NULL,
+    points   DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (points > 0),
+    position INT NOT NULL
+);
+
+CREATE INDEX idx_quiz_questions_quiz_id ON quiz_questions (quiz_id);
+
+CREATE TABLE quiz_options (
+    id          BIGSERIAL PRIMARY KEY,
+    question_id BIGINT NOT NULL REFERENCES quiz_questions (id) ON DELETE CASCADE,
+    text        TEXT NOT NULL,
+    is_correct  BOOLEAN NOT NULL DEFAULT FALSE,
+    position    INT NOT NULL
+);
+
+CREATE INDEX idx_quiz_options_question_id ON quiz_options (question_id);
@@ -0,0 +1,3 @@
+ALTER TABLE user_chapters DROP CONSTRAINT IF EXISTS user_chapters_user_id_chapter_id_key;
+DROP TABLE IF EXISTS quiz_attempts;
+ALTER TABLE chapters DROP COLUMN IF EXISTS max_attempts, DROP COLUMN IF EXISTS score_policy;
@@ -0,0 +1,47 @@
+ALTER TABLE chapters
+    ADD COLUMN score_policy VARCHAR(16) NOT NULL DEFAULT 'best'
+        CHECK (score_policy IN ('best', 'latest', 'average', 'first')),
+    ADD COLUMN max_attempts INT NOT NULL DEFAULT 0 CHECK (max_attempts >= 0);
+
+-- quiz_id is nullable so scores recorded before the quiz engine existed can
+-- be kept as attempts.
+CREATE TABLE quiz_attempts (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chapter_id     BIGINT NOT NULL REFERENCES chapters (id) ON DELETE CASCADE,
+    quiz_id        BIGINT REFERENCES quizzes (id) ON DELETE SET NULL,
+    attempt_number INT NOT NULL,
+    score          DOUBLE PRECISION NOT NULL,
+    earned_points  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    total_points   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    submitted_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, chapter_id, attempt_number)
+);
+
+-- Every legacy user_chapters row with a score becomes an attempt.
+INSERT INTO quiz_attempts (user_id, chapter_id, attempt_number, score, submitted_at)
+SELECT user_id, chapter_id,
+       ROW_NUMBER() OVER (PARTITION BY user_id, chapter_id ORDER BY created_at, id),
+       quiz_score, COALESCE(completed_at, created_at)
+FROM user_chapters
+WHERE quiz_score IS NOT NULL;
+
+-- Collapse duplicate progress rows into the oldest one, carrying over the
+-- best score (the default policy) and the earliest completion time.
+UPDATE user_chapters uc
+SET quiz_score = agg.best_score, completed_at = agg.first_completed_at
+FROM (
+    SELECT MIN(id) AS keep_id, MAX(quiz_score) AS best_score, MIN(completed_at) AS first_completed_at
+    FROM user_chapters
+    GROUP BY user_id, chapter_id
+    HAVING COUNT(*) > 1
+) agg
+WHERE uc.id = agg.keep_id;
+
+DELETE FROM user_chapters uc
+USING user_chapters keep
+WHERE uc.user_id = keep.user_id
+  AND uc.chapter_id = keep.chapter_id
+  AND uc.id > keep.id;
+
+ALTER TABLE user_chapters ADD CONSTRAINT user_chapters_user_id_chapter_id_key UNIQUE (user_id, chapter_id);
@@ -0,0 +1,3 @@
+-- The base URL the keys were stripped from is not known here; keys are
+-- resolved against APP_BASE_URL at read time, so they are left in place.
+SELECT 1;
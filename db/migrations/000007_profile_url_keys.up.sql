@@ -0,0 +1,5 @@
+-- profile_url now holds a storage key; the public URL is built at read time.
+-- Convert URLs of images uploaded to the local /uploads directory.
+UPDATE users
+SET profile_url = regexp_replace(profile_url, '^.*/uploads/', '')
+WHERE profile_url ~ '^https?://[^/]+/uploads/';
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN is_active  BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Supports the purge of soft-deleted users past their retention period.
+CREATE INDEX idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN class VARCHAR(100);
+
+UPDATE users u
+SET class = c.name
+FROM classes c
+WHERE c.id = u.class_id;
+
+DROP INDEX IF EXISTS idx_users_class_id;
+ALTER TABLE users DROP COLUMN IF EXISTS class_id;
+DROP TABLE IF EXISTS classes;
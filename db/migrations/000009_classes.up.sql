@@ -0,0 +1,39 @@
+CREATE TABLE classes (
+    id               BIGSERIAL PRIMARY KEY,
+    name             VARCHAR(100) NOT NULL,
+    -- "XI IPA 1", "XI-IPA-1" and "xi ipa  1" share one key.
+    name_key         VARCHAR(100) GENERATED ALWAYS AS (LOWER(TRIM(REGEXP_REPLACE(name, '[^[:alnum:]]+', ' ', 'g')))) STORED,
+    academic_year    VARCHAR(9) CHECK (academic_year ~ '^[0-9]{4}/[0-9]{4}$'),
+    homeroom_teacher VARCHAR(255),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX classes_name_key_academic_year_key ON classes (name_key, COALESCE(academic_year, ''));
+
+-- One class per distinct normalized spelling of users.class, named after
+-- its most common spelling. The academic year of legacy classes is unknown.
+INSERT INTO classes (name)
+SELECT DISTINCT ON (key) name
+FROM (
+    SELECT TRIM(class) AS name,
+           LOWER(TRIM(REGEXP_REPLACE(class, '[^[:alnum:]]+', ' ', 'g'))) AS key,
+           COUNT(*) AS uses
+    FROM users
+    WHERE class IS NOT NULL
+    GROUP BY 1, 2
+) spellings
+WHERE key <> ''
+ORDER BY key, uses DESC, name;
+
+ALTER TABLE users ADD COLUMN class_id BIGINT REFERENCES classes (id) ON DELETE RESTRICT;
+
+UPDATE users u
+SET class_id = c.id
+FROM classes c
+WHERE c.academic_year IS NULL
+  AND c.name_key = LOWER(TRIM(REGEXP_REPLACE(u.class, '[^[:alnum:]]+', ' ', 'g')));
+
+ALTER TABLE users DROP COLUMN class;
+
+CREATE INDEX idx_users_class_id ON users (class_id);
@@ -0,0 +1,10 @@
+-- Classes a guru (teacher) account is assigned to. A guru only sees the
+-- students and scores of these classes.
+CREATE TABLE class_teachers (
+    class_id   BIGINT NOT NULL REFERENCES classes (id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (class_id, user_id)
+);
+
+CREATE INDEX idx_class_teachers_user_id ON class_teachers (user_id);
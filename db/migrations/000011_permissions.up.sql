@@ -0,0 +1,33 @@
+CREATE TABLE permissions (
+    name        VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role       VARCHAR(50) NOT NULL,
+    permission VARCHAR(100) NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users.read', 'List students and view the student summary'),
+    ('users.create', 'Create guru accounts and import students'),
+    ('users.update', 'Edit user accounts'),
+    ('users.delete', 'Delete user accounts'),
+    ('users.deactivate', 'Deactivate and restore user accounts'),
+    ('admins.manage', 'List and create admin accounts'),
+    ('classes.manage', 'Create, edit and delete classes and assign their teachers'),
+    ('chapters.manage', 'Create, edit, reorder and delete chapters and their quizzes'),
+    ('quizzes.read_answers', 'View quizzes together with their answer keys'),
+    ('scores.read', 'View the quiz attempts of any user'),
+    ('scores.read_all', 'View and export the gradebook'),
+    ('permissions.manage', 'Manage which permissions each role has');
+
+-- Reproduce the previous hard-coded role checks.
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('guru', 'users.read'),
+    ('guru', 'users.update'),
+    ('guru', 'scores.read_all');
@@ -0,0 +1,5 @@
+INSERT INTO permissions (name, description) VALUES
+    ('users.unlock', 'Lift the login lockout of user accounts');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'users.unlock');
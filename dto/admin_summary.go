@@ -3,4 +3,5 @@ package dto
 type AdminSummary struct {
 	TotalAdmins int            `json:"totalAdmins"`
 	Admins      []UserResponse `json:"admins"`
+	Pagination  PaginationMeta `json:"pagination"`
 }
@@ -0,0 +1,19 @@
+package dto
+
+type CreateChapterRequest struct {
+	Name        string  `json:"name" binding:"required,max=255"`
+	Position    *int    `json:"position,omitempty" binding:"omitempty,min=1"`
+	ScorePolicy *string `json:"score_policy,omitempty" binding:"omitempty,oneof=best latest average first"`
+	MaxAttempts *int    `json:"max_attempts,omitempty" binding:"omitempty,min=0"`
+}
+
+type UpdateChapterRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,max=255"`
+	Position    *int    `json:"position,omitempty" binding:"omitempty,min=1"`
+	ScorePolicy *string `json:"score_policy,omitempty" binding:"omitempty,oneof=best latest average first"`
+	MaxAttempts *int    `json:"max_attempts,omitempty" binding:"omitempty,min=0"`
+}
+
+type ReorderChaptersRequest struct {
+	ChapterIDs []int64 `json:"chapter_ids" binding:"required,min=1,dive,gt=0"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+type CreateClassRequest struct {
+	Name            string  `json:"name" binding:"required,max=100"`
+	AcademicYear    *string `json:"academic_year,omitempty"`
+	HomeroomTeacher *string `json:"homeroom_teacher,omitempty" binding:"omitempty,max=255"`
+}
+
+// UpdateClassRequest is a partial update. An empty academic year or homeroom
+// teacher clears it.
+type UpdateClassRequest struct {
+	Name            *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	AcademicYear    *string `json:"academic_year,omitempty"`
+	HomeroomTeacher *string `json:"homeroom_teacher,omitempty" binding:"omitempty,max=255"`
+}
+
+// SetClassTeachersRequest replaces the guru accounts assigned to a class. An
+// empty list unassigns everyone.
+type SetClassTeachersRequest struct {
+	TeacherIDs []int64 `json:"teacher_ids" binding:"required,dive,min=1"`
+}
+
+// ClassTeacher is a guru assigned to a class.
+type ClassTeacher struct {
+	ID    int64  `json:"id" db:"id"`
+	Name  string `json:"name" db:"name"`
+	Email string `json:"email" db:"email"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// HealthCheck is the outcome of one readiness check. Status is "ok" or
+// "fail"; error details are only logged, since the endpoint is public.
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Detail    string  `json:"detail,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// ReadinessReport is returned by /readyz. Draining is set while the server
+// shuts down; the checks are skipped then.
+type ReadinessReport struct {
+	Ready    bool          `json:"ready"`
+	Draining bool          `json:"draining"`
+	Checks   []HealthCheck `json:"checks"`
+}
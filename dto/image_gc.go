@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// ImageGCReport summarizes one reconciliation of stored profile images
+// against users.profile_url.
+type ImageGCReport struct {
+	DryRun     bool            `json:"dry_run"`
+	Scanned    int             `json:"scanned"`
+	Referenced int             `json:"referenced"`
+	Orphans    []ImageGCOrphan `json:"orphans"`
+	Deleted    int             `json:"deleted"`
+	FreedBytes int64           `json:"freed_bytes"`
+	Errors     []string        `json:"errors,omitempty"`
+}
+
+type ImageGCOrphan struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
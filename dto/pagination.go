@@ -0,0 +1,73 @@
+package dto
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ListUsersQuery is bound from the query string of the user listing
+// endpoints, e.g. ?page=2&limit=50&class_id=3&search=budi&sort=name&order=desc.
+// Status selects deactivated or deleted accounts instead of active ones.
+type ListUsersQuery struct {
+	Status  string `form:"status" binding:"omitempty,oneof=active inactive deleted all"`
+	Page    int    `form:"page" binding:"omitempty,min=1"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	ClassID int64  `form:"class_id" binding:"omitempty,min=1"`
+	Search  string `form:"search"`
+	Sort    string `form:"sort" binding:"omitempty,oneof=name created_at class"`
+	Order   string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// ApplyDefaults fills in the page, limit and ordering left empty by the
+// client.
+func (q *ListUsersQuery) ApplyDefaults() {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	if q.Sort == "" {
+		q.Sort = "name"
+	}
+	if q.Order == "" {
+		q.Order = "asc"
+	}
+	if q.Status == "" {
+		q.Status = "active"
+	}
+}
+
+func (q *ListUsersQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+type PaginationMeta struct {
+	Page       int  `json:"page"`
+	Limit      int  `json:"limit"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"total_pages"`
+	HasNext    bool `json:"has_next"`
+}
+
+func NewPaginationMeta(page, limit, total int) PaginationMeta {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = (total + limit - 1) / limit
+	}
+	return PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+	}
+}
+
+type UserListResponse struct {
+	Data       []*UserResponse `json:"data"`
+	Pagination PaginationMeta  `json:"pagination"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// RolePermissions lists the permissions granted to a role.
+type RolePermissions struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// SetRolePermissionsRequest replaces every permission of a role.
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required,dive,required"`
+}
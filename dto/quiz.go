@@ -0,0 +1,93 @@
+package dto
+
+import (
+	"be-education/models"
+	"time"
+)
+
+type UpsertQuizRequest struct {
+	Title       string              `json:"title" binding:"required,max=255"`
+	Description *string             `json:"description,omitempty"`
+	Questions   []QuizQuestionInput `json:"questions" binding:"required,min=1,dive"`
+}
+
+type QuizQuestionInput struct {
+	Type            string            `json:"type" binding:"required,oneof=single_choice multiple_choice short_answer"`
+	Prompt          string            `json:"prompt" binding:"required"`
+	Points          *float64          `json:"points,omitempty" binding:"omitempty,gt=0"`
+	Options         []QuizOptionInput `json:"options,omitempty" binding:"dive"`
+	AcceptedAnswers []string          `json:"accepted_answers,omitempty"`
+}
+
+type QuizOptionInput struct {
+	Text      string `json:"text" binding:"required"`
+	IsCorrect bool   `json:"is_correct"`
+}
+
+// QuizResponse is shared by the student and admin views. The answer key
+// fields are only populated for the admin view.
+type QuizResponse struct {
+	ID          int64                  `json:"id"`
+	ChapterID   int64                  `json:"chapter_id"`
+	Title       string                 `json:"title"`
+	Description *string                `json:"description,omitempty"`
+	TotalPoints float64                `json:"total_points"`
+	Questions   []QuizQuestionResponse `json:"questions"`
+}
+
+type QuizQuestionResponse struct {
+	ID              int64                `json:"id"`
+	Type            string               `json:"type"`
+	Prompt          string               `json:"prompt"`
+	Points          float64              `json:"points"`
+	Options         []QuizOptionResponse `json:"options,omitempty"`
+	AcceptedAnswers []string             `json:"accepted_answers,omitempty"`
+}
+
+type QuizOptionResponse struct {
+	ID        int64  `json:"id"`
+	Text      string `json:"text"`
+	IsCorrect *bool  `json:"is_correct,omitempty"`
+}
+
+type SubmitQuizRequest struct {
+	Answers []QuizAnswerInput `json:"answers" binding:"required,dive"`
+}
+
+type QuizAnswerInput struct {
+	QuestionID int64   `json:"question_id" binding:"required"`
+	OptionIDs  []int64 `json:"option_ids,omitempty"`
+	Text       *string `json:"text,omitempty"`
+}
+
+type QuizResultResponse struct {
+	QuizID            int64                `json:"quiz_id"`
+	ChapterID         int64                `json:"chapter_id"`
+	AttemptNumber     int                  `json:"attempt_number"`
+	AttemptsRemaining *int                 `json:"attempts_remaining,omitempty"`
+	ScorePolicy       string               `json:"score_policy"`
+	ChapterScore      *float64             `json:"chapter_score"`
+	Score             float64              `json:"score"`
+	EarnedPoints      float64              `json:"earned_points"`
+	TotalPoints       float64              `json:"total_points"`
+	CorrectCount      int                  `json:"correct_count"`
+	TotalQuestions    int                  `json:"total_questions"`
+	CompletedAt       time.Time            `json:"completed_at"`
+	Questions         []QuizQuestionResult `json:"questions"`
+}
+
+type QuizQuestionResult struct {
+	QuestionID   int64   `json:"question_id"`
+	Correct      bool    `json:"correct"`
+	EarnedPoints float64 `json:"earned_points"`
+}
+
+type QuizAttemptHistoryResponse struct {
+	UserID            int64                 `json:"user_id"`
+	ChapterID         int64                 `json:"chapter_id"`
+	ScorePolicy       string                `json:"score_policy"`
+	MaxAttempts       int                   `json:"max_attempts"`
+	AttemptsRemaining *int                  `json:"attempts_remaining,omitempty"`
+	ChapterScore      *float64              `json:"chapter_score"`
+	Attempts          []*models.QuizAttempt `json:"attempts"`
+}
@@ -1,6 +1,9 @@
 package dto
 
-type StudentCount struct {
-	Class string `db:"class"`
-	Total int    `db:"total"`
+// ClassStudentCount is the number of active students in one class. ClassID
+// is nil for students without a class.
+type ClassStudentCount struct {
+	ClassID   *int64 `db:"class_id" json:"classId"`
+	ClassName string `db:"class_name" json:"className"`
+	Total     int    `db:"total" json:"total"`
 }
@@ -0,0 +1,40 @@
+package dto
+
+const (
+	PasswordModeGenerate  = "generate"
+	PasswordModeResetLink = "reset_link"
+)
+
+// StudentImportQuery is bound from the query string of the import endpoint.
+// PasswordMode decides what happens to rows without a password column value:
+// "generate" returns a random initial password in the response, "reset_link"
+// emails the student a password reset link instead.
+type StudentImportQuery struct {
+	DryRun       bool   `form:"dry_run"`
+	PasswordMode string `form:"password_mode" binding:"omitempty,oneof=generate reset_link"`
+}
+
+type StudentImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type StudentImportAccount struct {
+	Row             int    `json:"row"`
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Class           string `json:"class,omitempty"`
+	InitialPassword string `json:"initial_password,omitempty"`
+	ResetLinkSent   bool   `json:"reset_link_sent,omitempty"`
+}
+
+type StudentImportResult struct {
+	DryRun      bool                    `json:"dry_run"`
+	TotalRows   int                     `json:"total_rows"`
+	ValidRows   int                     `json:"valid_rows"`
+	InvalidRows int                     `json:"invalid_rows"`
+	Imported    int                     `json:"imported"`
+	Errors      []StudentImportRowError `json:"errors"`
+	Accounts    []StudentImportAccount  `json:"accounts"`
+}
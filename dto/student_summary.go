@@ -1,6 +1,6 @@
 package dto
 
 type StudentSummary struct {
-	TotalStudents int            `json:"totalStudents"`
-	ClassCounts   map[string]int `json:"classCounts"`
+	TotalStudents int                 `json:"totalStudents"`
+	Classes       []ClassStudentCount `json:"classes"`
 }
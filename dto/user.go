@@ -6,7 +6,7 @@ type CreateUserRequest struct {
 	Name     string     `json:"name" binding:"required"`
 	Email    string     `json:"email" binding:"required,email"`
 	Password string     `json:"password" binding:"required,min=6"`
-	Class    *string    `json:"class,omitempty"`
+	ClassID  *int64     `json:"class_id,omitempty" binding:"omitempty,min=1"`
 	Birthday *time.Time `json:"birthday,omitempty"`
 }
 
@@ -16,13 +16,46 @@ type LoginUserRequest struct {
 }
 
 type UserResponse struct {
-	ID         int64     `json:"id"`
-	Name       string    `json:"name"`
-	Email      string    `json:"email"`
-	Class      string    `json:"class"`
-	Birthday   string    `json:"birthday"`
-	Role       string    `json:"role"`
-	ProfileURL string    `json:"profile_url"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID           int64         `json:"id"`
+	Name         string        `json:"name"`
+	Email        string        `json:"email"`
+	ClassID      *int64        `json:"class_id"`
+	Class        string        `json:"class"`
+	Birthday     string        `json:"birthday"`
+	Role         string        `json:"role"`
+	ProfileImage *ProfileImage `json:"profile_image"`
+	IsActive     bool          `json:"is_active"`
+	DeletedAt    *time.Time    `json:"deleted_at,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// ProfileImage lists the URLs of a profile picture. Variants maps the edge
+// length in pixels ("64", "256", "512") to a square thumbnail.
+type ProfileImage struct {
+	Original string            `json:"original"`
+	Variants map[string]string `json:"variants"`
+}
+
+// UpdateProfileRequest is a partial update: omitted fields are left as they
+// are. Users cannot change their own class, because the class decides which
+// gurus see them.
+type UpdateProfileRequest struct {
+	Name     *string    `json:"name" binding:"omitempty,max=255"`
+	Birthday *time.Time `json:"birthday"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// AdminUpdateUserRequest extends the profile fields with class, email and
+// role. Sending class_id 0 removes the user from their class. Only admins may
+// change the role.
+type AdminUpdateUserRequest struct {
+	UpdateProfileRequest
+	ClassID *int64  `json:"class_id" binding:"omitempty,min=0"`
+	Email   *string `json:"email" binding:"omitempty,email"`
+	Role    *string `json:"role" binding:"omitempty,oneof=admin guru mahasiswa"`
 }
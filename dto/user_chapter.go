@@ -2,10 +2,11 @@ package dto
 
 import "time"
 
+// CreateUserChapterRequest marks a chapter as completed. Quiz scores are not
+// accepted from the client; they are computed by the quiz submission endpoint.
 type CreateUserChapterRequest struct {
 	ChapterID   int64      `json:"chapter_id" binding:"required"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
-	QuizScore   *float64   `json:"quiz_score,omitempty"`
 }
 
 type UserChapterQuizScoreResponse struct {
@@ -2,12 +2,34 @@ package dto
 
 // UserChapterScore represents a user's score for a specific chapter.
 // This is used for combining user information with their chapter scores.
+// ChapterID is 0 for students without any chapter progress; Score is nil
+// when the chapter has no quiz score.
 type UserChapterScore struct {
-	UserID    int64   `db:"user_id" json:"userId"`
-	UserName  string  `db:"user_name" json:"userName"`
-	UserClass string  `db:"user_class" json:"userClass"`
-	ChapterID int64   `db:"chapter_id" json:"chapterId"`
-	Score     float64 `db:"score" json:"score"`
+	UserID    int64    `db:"user_id" json:"userId"`
+	UserName  string   `db:"user_name" json:"userName"`
+	ClassID   *int64   `db:"class_id" json:"classId"`
+	UserClass string   `db:"user_class" json:"userClass"`
+	ChapterID int64    `db:"chapter_id" json:"chapterId"`
+	Score     *float64 `db:"score" json:"score"`
+}
+
+// GradebookScore is one row of the gradebook export query. ChapterID is 0
+// and Score nil for students without any chapter progress.
+type GradebookScore struct {
+	UserID    int64    `db:"user_id"`
+	UserName  string   `db:"user_name"`
+	ClassID   *int64   `db:"class_id"`
+	UserClass string   `db:"user_class"`
+	ChapterID int64    `db:"chapter_id"`
+	Score     *float64 `db:"score"`
+}
+
+// GradebookChapter names a chapter key used in UserScoreEntry.ChapterScores.
+type GradebookChapter struct {
+	Key      string `json:"key"`
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
 }
 
 // UserScoreEntry represents a single user's summary for chapter scores.
@@ -15,11 +37,30 @@ type UserChapterScore struct {
 type UserScoreEntry struct {
 	ID            int64              `json:"id"`
 	Name          string             `json:"name"`
+	ClassID       *int64             `json:"classId"`
 	Class         string             `json:"class"`
 	ChapterScores map[string]float64 `json:"chapterScores"`
 }
 
+// ClassScoreSummary holds the per-chapter averages of one class. ClassID is
+// nil for students without a class.
+type ClassScoreSummary struct {
+	ClassID         *int64             `json:"classId"`
+	ClassName       string             `json:"className"`
+	Students        int                `json:"students"`
+	ChapterAverages map[string]float64 `json:"chapterAverages"`
+	Average         *float64           `json:"average"`
+}
+
 // UserChapterScoresSummary represents the summary of all users with their chapter scores.
 type UserChapterScoresSummary struct {
-	UsersScores []UserScoreEntry `json:"usersScores"` // Now uses the named UserScoreEntry
+	Chapters    []GradebookChapter  `json:"chapters"`
+	Classes     []ClassScoreSummary `json:"classes"`
+	UsersScores []UserScoreEntry    `json:"usersScores"` // Now uses the named UserScoreEntry
+}
+
+// GradebookQuery is bound from the query string of the all-scores summary.
+type GradebookQuery struct {
+	Format  string `form:"format" binding:"omitempty,oneof=json csv xlsx pdf"`
+	ClassID int64  `form:"class_id" binding:"omitempty,min=1"`
 }
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvFlushEvery bounds how many rows sit in the csv.Writer buffer before
+// they are pushed to the client.
+const csvFlushEvery = 200
+
+type csvWriter struct {
+	w       *csv.Writer
+	pending int
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	// A UTF-8 BOM makes Excel open the file with the right encoding.
+	w.Write([]byte{0xEF, 0xBB, 0xBF})
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(columns []Column) error {
+	record := []string{"Name", "Class"}
+	for _, column := range columns {
+		record = append(record, csvText(column.Name))
+	}
+	record = append(record, "Average")
+	return c.write(record)
+}
+
+func (c *csvWriter) WriteStudent(row StudentRow) error {
+	record := []string{csvText(row.Name), csvText(row.Class)}
+	for _, score := range row.Scores {
+		record = append(record, formatScore(score))
+	}
+	record = append(record, formatScore(row.Average))
+	return c.write(record)
+}
+
+func (c *csvWriter) WriteClassSummary(summary ClassSummary) error {
+	record := []string{fmt.Sprintf("Class average (%d students)", summary.Students), csvText(classLabel(summary.Class))}
+	for _, avg := range summary.Averages {
+		record = append(record, formatScore(avg))
+	}
+	record = append(record, formatScore(summary.Average))
+	if err := c.write(record); err != nil {
+		return err
+	}
+	return c.write([]string{})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvText neutralizes user-supplied text that spreadsheet applications
+// would otherwise evaluate as a formula, such as "=HYPERLINK(...)".
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (c *csvWriter) write(record []string) error {
+	if err := c.w.Write(record); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
+	}
+	c.pending++
+	if c.pending >= csvFlushEvery {
+		c.pending = 0
+		c.w.Flush()
+		return c.w.Error()
+	}
+	return nil
+}
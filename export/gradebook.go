@@ -0,0 +1,88 @@
+// Package export renders the chapter-score gradebook as CSV, XLSX or PDF.
+// Rows are written one at a time so callers can stream straight from the
+// database cursor.
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// Column is one chapter column, in chapter order.
+type Column struct {
+	ChapterID int64
+	Name      string
+}
+
+// StudentRow holds one student's scores aligned with the header columns. A
+// nil score means the student has no score for that chapter.
+type StudentRow struct {
+	Name    string
+	Class   string
+	Scores  []*float64
+	Average *float64
+}
+
+// ClassSummary is the footer written after the last student of a class.
+type ClassSummary struct {
+	Class    string
+	Students int
+	Averages []*float64
+	Average  *float64
+}
+
+type GradebookWriter interface {
+	WriteHeader(columns []Column) error
+	WriteStudent(row StudentRow) error
+	WriteClassSummary(summary ClassSummary) error
+	// Close flushes anything still buffered to the underlying writer.
+	Close() error
+}
+
+// NewGradebookWriter returns a writer for the given format. title is used by
+// formats that carry a document title.
+func NewGradebookWriter(format string, w io.Writer, title string) (GradebookWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	case FormatPDF:
+		return newPDFWriter(w, title), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func classLabel(class string) string {
+	if class == "" {
+		return "(no class)"
+	}
+	return class
+}
+
+func formatScore(score *float64) string {
+	if score == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", *score)
+}
@@ -0,0 +1,149 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfMargin      = 10.0
+	pdfRowHeight   = 6.0
+	pdfNameWidth   = 55.0
+	pdfClassWidth  = 22.0
+	pdfAvgWidth    = 18.0
+	pdfMinColWidth = 10.0
+)
+
+// pdfWriter lays the gradebook out as a landscape A4 table. Unlike CSV and
+// XLSX the PDF is assembled in memory by fpdf and written out on Close; a
+// printable gradebook is only practical for a few hundred students anyway.
+type pdfWriter struct {
+	out       io.Writer
+	pdf       *fpdf.Fpdf
+	tr        func(string) string
+	columns   []Column
+	colWidth  float64
+	rowNumber int
+}
+
+func newPDFWriter(w io.Writer, title string) *pdfWriter {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.SetTitle(title, true)
+
+	p := &pdfWriter{out: w, pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	generatedAt := time.Now().Format("2006-01-02 15:04")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Generated %s - page %d", generatedAt, pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, p.tr(title), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+	return p
+}
+
+func (p *pdfWriter) WriteHeader(columns []Column) error {
+	p.columns = columns
+
+	pageWidth, _ := p.pdf.GetPageSize()
+	available := pageWidth - 2*pdfMargin - pdfNameWidth - pdfClassWidth - pdfAvgWidth
+	p.colWidth = pdfMinColWidth
+	if len(columns) > 0 && available/float64(len(columns)) > pdfMinColWidth {
+		p.colWidth = available / float64(len(columns))
+	}
+
+	p.drawHeader()
+	return p.pdf.Error()
+}
+
+func (p *pdfWriter) WriteStudent(row StudentRow) error {
+	p.ensureSpace()
+	p.rowNumber++
+
+	fill := p.rowNumber%2 == 0
+	p.pdf.SetFillColor(245, 245, 245)
+	p.pdf.SetFont("Helvetica", "", 8)
+	p.cell(pdfNameWidth, row.Name, "L", fill)
+	p.cell(pdfClassWidth, row.Class, "L", fill)
+	for _, score := range row.Scores {
+		p.cell(p.colWidth, formatScore(score), "R", fill)
+	}
+	p.cell(pdfAvgWidth, formatScore(row.Average), "R", fill)
+	p.pdf.Ln(-1)
+	return p.pdf.Error()
+}
+
+func (p *pdfWriter) WriteClassSummary(summary ClassSummary) error {
+	p.ensureSpace()
+
+	p.pdf.SetFillColor(220, 220, 220)
+	p.pdf.SetFont("Helvetica", "B", 8)
+	p.cell(pdfNameWidth, fmt.Sprintf("Class average (%d students)", summary.Students), "L", true)
+	p.cell(pdfClassWidth, classLabel(summary.Class), "L", true)
+	for _, avg := range summary.Averages {
+		p.cell(p.colWidth, formatScore(avg), "R", true)
+	}
+	p.cell(pdfAvgWidth, formatScore(summary.Average), "R", true)
+	p.pdf.Ln(-1)
+	p.pdf.Ln(3)
+	p.rowNumber = 0
+	return p.pdf.Error()
+}
+
+func (p *pdfWriter) Close() error {
+	if err := p.pdf.Output(p.out); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
+
+func (p *pdfWriter) drawHeader() {
+	p.pdf.SetFillColor(200, 200, 200)
+	p.pdf.SetFont("Helvetica", "B", 8)
+	p.cell(pdfNameWidth, "Name", "L", true)
+	p.cell(pdfClassWidth, "Class", "L", true)
+	for _, column := range p.columns {
+		p.cell(p.colWidth, column.Name, "C", true)
+	}
+	p.cell(pdfAvgWidth, "Average", "R", true)
+	p.pdf.Ln(-1)
+}
+
+// ensureSpace starts a new page, repeating the header, when the next row
+// would run into the footer.
+func (p *pdfWriter) ensureSpace() {
+	_, pageHeight := p.pdf.GetPageSize()
+	if p.pdf.GetY()+pdfRowHeight > pageHeight-2*pdfMargin {
+		p.pdf.AddPage()
+		p.drawHeader()
+	}
+}
+
+func (p *pdfWriter) cell(width float64, text, align string, fill bool) {
+	p.pdf.CellFormat(width, pdfRowHeight, p.fit(text, width), "1", 0, align, fill, 0, "")
+}
+
+// fit truncates text with an ellipsis so it stays inside its cell.
+func (p *pdfWriter) fit(text string, width float64) string {
+	text = p.tr(text)
+	limit := width - 2
+	if p.pdf.GetStringWidth(text) <= limit {
+		return text
+	}
+	// After translation every character is a single cp1252 byte, so slicing
+	// bytes is safe here.
+	ellipsis := p.tr("…")
+	for len(text) > 0 && p.pdf.GetStringWidth(text+ellipsis) > limit {
+		text = text[:len(text)-1]
+	}
+	return text + ellipsis
+}
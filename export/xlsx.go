@@ -0,0 +1,126 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const xlsxSheet = "Gradebook"
+
+// xlsxWriter uses excelize's stream writer, which spills rows to a temporary
+// file instead of holding the whole sheet in memory.
+type xlsxWriter struct {
+	out        io.Writer
+	file       *excelize.File
+	stream     *excelize.StreamWriter
+	row        int
+	boldStyle  int
+	scoreStyle int
+	footStyle  int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName("Sheet1", xlsxSheet); err != nil {
+		return nil, fmt.Errorf("failed to prepare XLSX sheet: %w", err)
+	}
+
+	stream, err := f.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX stream: %w", err)
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create XLSX style: %w", err)
+	}
+	decimals := "0.00"
+	score, err := f.NewStyle(&excelize.Style{CustomNumFmt: &decimals})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create XLSX style: %w", err)
+	}
+	foot, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &decimals})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create XLSX style: %w", err)
+	}
+
+	return &xlsxWriter{out: w, file: f, stream: stream, boldStyle: bold, scoreStyle: score, footStyle: foot}, nil
+}
+
+func (x *xlsxWriter) WriteHeader(columns []Column) error {
+	// Column widths must be set before the first row is streamed.
+	if err := x.stream.SetColWidth(1, 1, 30); err != nil {
+		return err
+	}
+	if err := x.stream.SetColWidth(2, len(columns)+3, 14); err != nil {
+		return err
+	}
+
+	cells := []interface{}{
+		excelize.Cell{StyleID: x.boldStyle, Value: "Name"},
+		excelize.Cell{StyleID: x.boldStyle, Value: "Class"},
+	}
+	for _, column := range columns {
+		cells = append(cells, excelize.Cell{StyleID: x.boldStyle, Value: column.Name})
+	}
+	cells = append(cells, excelize.Cell{StyleID: x.boldStyle, Value: "Average"})
+	return x.writeRow(cells)
+}
+
+func (x *xlsxWriter) WriteStudent(row StudentRow) error {
+	cells := []interface{}{row.Name, row.Class}
+	for _, score := range row.Scores {
+		cells = append(cells, x.scoreCell(score, x.scoreStyle))
+	}
+	cells = append(cells, x.scoreCell(row.Average, x.scoreStyle))
+	return x.writeRow(cells)
+}
+
+func (x *xlsxWriter) WriteClassSummary(summary ClassSummary) error {
+	cells := []interface{}{
+		excelize.Cell{StyleID: x.boldStyle, Value: fmt.Sprintf("Class average (%d students)", summary.Students)},
+		excelize.Cell{StyleID: x.boldStyle, Value: classLabel(summary.Class)},
+	}
+	for _, avg := range summary.Averages {
+		cells = append(cells, x.scoreCell(avg, x.footStyle))
+	}
+	cells = append(cells, x.scoreCell(summary.Average, x.footStyle))
+	if err := x.writeRow(cells); err != nil {
+		return err
+	}
+	x.row++ // blank spacer row between classes
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return fmt.Errorf("failed to flush XLSX stream: %w", err)
+	}
+	if err := x.file.Write(x.out); err != nil {
+		return fmt.Errorf("failed to write XLSX: %w", err)
+	}
+	return nil
+}
+
+func (x *xlsxWriter) scoreCell(score *float64, style int) interface{} {
+	if score == nil {
+		return nil
+	}
+	return excelize.Cell{StyleID: style, Value: *score}
+}
+
+func (x *xlsxWriter) writeRow(cells []interface{}) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	if err := x.stream.SetRow(cell, cells); err != nil {
+		return fmt.Errorf("failed to write XLSX row %d: %w", x.row, err)
+	}
+	return nil
+}
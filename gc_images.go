@@ -0,0 +1,84 @@
+package main
+
+import (
+	"be-education/db"
+	"be-education/repository"
+	"be-education/service"
+	"be-education/storage"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+)
+
+// runGCImages implements the "gc-images" subcommand: it reconciles stored
+// profile images against users.profile_url and deletes the orphans, or only
+// lists them with -dry-run.
+func runGCImages(args []string) int {
+	fs := flag.NewFlagSet("gc-images", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only report what would be deleted")
+	gracePeriod := fs.Duration("grace", 0, "keep files younger than this (default IMAGE_GC_GRACE_PERIOD)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, ok := loadConfig()
+	if !ok {
+		return 1
+	}
+	if *gracePeriod <= 0 {
+		*gracePeriod = cfg.ImageGC.GracePeriod
+	}
+
+	dbConn, err := db.Connect(cfg)
+	if err != nil {
+		slog.Error("Failed to connect to the database", "error", err)
+		return 1
+	}
+	defer db.Close(dbConn)
+
+	files, err := storage.New(cfg)
+	if err != nil {
+		slog.Error("Failed to set up file storage", "error", err)
+		return 1
+	}
+
+	imageGC := service.NewProfileImageGCService(repository.NewUserRepository(dbConn), files, *gracePeriod)
+	report, err := imageGC.Sweep(context.Background(), *dryRun)
+	if err != nil {
+		slog.Error("Profile image GC failed", "error", err)
+		return 1
+	}
+
+	if len(report.Orphans) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tSIZE\tMODIFIED")
+		for _, orphan := range report.Orphans {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", orphan.Key, orphan.Size, orphan.ModTime.Format("2006-01-02 15:04:05"))
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	var totalSize int64
+	for _, orphan := range report.Orphans {
+		totalSize += orphan.Size
+	}
+
+	fmt.Printf("Scanned %d file(s), %d referenced, %d orphaned (%d bytes)\n", report.Scanned, report.Referenced, len(report.Orphans), totalSize)
+	if report.DryRun {
+		fmt.Println("Dry run: nothing was deleted")
+		return 0
+	}
+
+	fmt.Printf("Deleted %d file(s), freed %d bytes\n", report.Deleted, report.FreedBytes)
+	for _, msg := range report.Errors {
+		fmt.Fprintf(os.Stderr, "error: %s\n", msg)
+	}
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/service"
+	"be-education/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type authHandlerImpl struct {
+	tokenService         service.TokenService
+	passwordResetService service.PasswordResetService
+}
+
+func NewAuthHandler(tokenService service.TokenService, passwordResetService service.PasswordResetService) *authHandlerImpl {
+	return &authHandlerImpl{tokenService: tokenService, passwordResetService: passwordResetService}
+}
+
+func (h *authHandlerImpl) Refresh(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	tokens, err := h.tokenService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *authHandlerImpl) Logout(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok || claims == nil {
+		c.Error(apperrors.Unauthorized("User not authenticated or claims not found"))
+		return
+	}
+
+	var req dto.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.InvalidRequest(err))
+			return
+		}
+	}
+
+	err := h.tokenService.Logout(c.Request.Context(), claims, req.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
+}
+
+func (h *authHandlerImpl) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	if err := h.passwordResetService.RequestReset(c.Request.Context(), req.Email); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account with that email exists, a password reset link has been sent"})
+}
+
+func (h *authHandlerImpl) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	err := h.passwordResetService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully. Please login again."})
+}
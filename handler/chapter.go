@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type chapterHandlerImpl struct {
+	chapterService service.ChapterService
+}
+
+func NewChapterHandler(chapterService service.ChapterService) *chapterHandlerImpl {
+	return &chapterHandlerImpl{chapterService: chapterService}
+}
+
+func (h *chapterHandlerImpl) GetAllChapters(c *gin.Context) {
+	chapters, err := h.chapterService.GetAllChapters(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Chapters retrieved successfully", "data": chapters})
+}
+
+func (h *chapterHandlerImpl) GetChapter(c *gin.Context) {
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	chapter, err := h.chapterService.GetChapterByID(c.Request.Context(), chapterID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Chapter retrieved successfully", "data": chapter})
+}
+
+func (h *chapterHandlerImpl) CreateChapter(c *gin.Context) {
+	var req dto.CreateChapterRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	chapter, err := h.chapterService.CreateChapter(c.Request.Context(), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Chapter created successfully", "data": chapter})
+}
+
+func (h *chapterHandlerImpl) UpdateChapter(c *gin.Context) {
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	var req dto.UpdateChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	chapter, err := h.chapterService.UpdateChapter(c.Request.Context(), chapterID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Chapter updated successfully", "data": chapter})
+}
+
+func (h *chapterHandlerImpl) DeleteChapter(c *gin.Context) {
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	if err := h.chapterService.DeleteChapter(c.Request.Context(), chapterID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Chapter deleted successfully"})
+}
+
+func (h *chapterHandlerImpl) ReorderChapters(c *gin.Context) {
+	var req dto.ReorderChaptersRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	chapters, err := h.chapterService.ReorderChapters(c.Request.Context(), req.ChapterIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Chapters reordered successfully", "data": chapters})
+}
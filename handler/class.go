@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/service"
+	"be-education/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type classHandlerImpl struct {
+	classService service.ClassService
+}
+
+func NewClassHandler(classService service.ClassService) *classHandlerImpl {
+	return &classHandlerImpl{classService: classService}
+}
+
+func (h *classHandlerImpl) GetAllClasses(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	classes, err := h.classService.GetAllClasses(c.Request.Context(), scope, c.Query("academic_year"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Classes retrieved successfully", "data": classes})
+}
+
+func (h *classHandlerImpl) GetClass(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	classID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid class ID format").Wrap(err))
+		return
+	}
+
+	class, err := h.classService.GetClassByID(c.Request.Context(), scope, classID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class retrieved successfully", "data": class})
+}
+
+func (h *classHandlerImpl) CreateClass(c *gin.Context) {
+	var req dto.CreateClassRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	class, err := h.classService.CreateClass(c.Request.Context(), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Class created successfully", "data": class})
+}
+
+func (h *classHandlerImpl) UpdateClass(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid class ID format").Wrap(err))
+		return
+	}
+
+	var req dto.UpdateClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	class, err := h.classService.UpdateClass(c.Request.Context(), classID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class updated successfully", "data": class})
+}
+
+func (h *classHandlerImpl) DeleteClass(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid class ID format").Wrap(err))
+		return
+	}
+
+	if err := h.classService.DeleteClass(c.Request.Context(), classID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class deleted successfully"})
+}
+
+func (h *classHandlerImpl) GetClassTeachers(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	classID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid class ID format").Wrap(err))
+		return
+	}
+
+	teachers, err := h.classService.GetClassTeachers(c.Request.Context(), scope, classID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class teachers retrieved successfully", "data": teachers})
+}
+
+func (h *classHandlerImpl) SetClassTeachers(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid class ID format").Wrap(err))
+		return
+	}
+
+	var req dto.SetClassTeachersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	teachers, err := h.classService.SetClassTeachers(c.Request.Context(), classID, req.TeacherIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Class teachers updated successfully", "data": teachers})
+}
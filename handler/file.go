@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"be-education/apperrors"
+	"be-education/storage"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fileHandlerImpl struct {
+	files storage.Storage
+}
+
+func NewFileHandler(files storage.Storage) *fileHandlerImpl {
+	return &fileHandlerImpl{files: files}
+}
+
+// ServeFile streams a stored file. It backs the /uploads URLs handed out by
+// the storage backend when files are not served from a public bucket.
+func (h *fileHandlerImpl) ServeFile(c *gin.Context) {
+	key, err := storage.CleanKey(c.Param("key"))
+	if err != nil {
+		c.Error(apperrors.NotFound("file not found"))
+		return
+	}
+
+	reader, object, err := h.files.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.Error(apperrors.NotFound("file not found"))
+			return
+		}
+		c.Error(fmt.Errorf("failed to read stored file %s: %w", key, err))
+		return
+	}
+	defer reader.Close()
+
+	contentType := object.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Keys are never reused, so the content behind a URL does not change.
+	c.DataFromReader(http.StatusOK, object.Size, contentType, reader, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
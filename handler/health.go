@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"be-education/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type healthHandlerImpl struct {
+	healthService service.HealthService
+}
+
+func NewHealthHandler(healthService service.HealthService) *healthHandlerImpl {
+	return &healthHandlerImpl{healthService: healthService}
+}
+
+// Liveness only reports that the process serves requests; dependency
+// failures must not get a healthy instance restarted.
+func (h *healthHandlerImpl) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Service is alive", "data": gin.H{"status": service.HealthStatusOK}})
+}
+
+// Readiness answers 503 while a dependency is unavailable or the server is
+// draining, so the instance is taken out of rotation.
+func (h *healthHandlerImpl) Readiness(c *gin.Context) {
+	report := h.healthService.Ready(c.Request.Context())
+	if !report.Ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"message": "Service is not ready", "data": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service is ready", "data": report})
+}
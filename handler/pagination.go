@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"be-education/dto"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setPaginationHeaders mirrors the pagination metadata in headers so clients
+// can page without parsing the body.
+func setPaginationHeaders(c *gin.Context, meta dto.PaginationMeta) {
+	c.Header("X-Total-Count", strconv.Itoa(meta.Total))
+	c.Header("X-Page", strconv.Itoa(meta.Page))
+	c.Header("X-Per-Page", strconv.Itoa(meta.Limit))
+	c.Header("X-Total-Pages", strconv.Itoa(meta.TotalPages))
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type permissionHandlerImpl struct {
+	permissionService service.PermissionService
+}
+
+func NewPermissionHandler(permissionService service.PermissionService) *permissionHandlerImpl {
+	return &permissionHandlerImpl{permissionService: permissionService}
+}
+
+func (h *permissionHandlerImpl) GetAllPermissions(c *gin.Context) {
+	permissions, err := h.permissionService.GetAllPermissions(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permissions retrieved successfully", "data": permissions})
+}
+
+func (h *permissionHandlerImpl) GetRolePermissions(c *gin.Context) {
+	roles, err := h.permissionService.GetRolePermissions(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role permissions retrieved successfully", "data": roles})
+}
+
+func (h *permissionHandlerImpl) SetRolePermissions(c *gin.Context) {
+	var req dto.SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	role, err := h.permissionService.SetRolePermissions(c.Request.Context(), c.Param("role"), req.Permissions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role permissions updated successfully", "data": role})
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/service"
+	"be-education/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type quizHandlerImpl struct {
+	quizService service.QuizService
+}
+
+func NewQuizHandler(quizService service.QuizService) *quizHandlerImpl {
+	return &quizHandlerImpl{quizService: quizService}
+}
+
+func (h *quizHandlerImpl) GetQuiz(c *gin.Context) {
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	quiz, err := h.quizService.GetQuizForStudent(c.Request.Context(), chapterID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quiz retrieved successfully", "data": quiz})
+}
+
+func (h *quizHandlerImpl) GetQuizWithAnswers(c *gin.Context) {
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	quiz, err := h.quizService.GetQuizWithAnswers(c.Request.Context(), chapterID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quiz retrieved successfully", "data": quiz})
+}
+
+func (h *quizHandlerImpl) UpsertQuiz(c *gin.Context) {
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	var req dto.UpsertQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	quiz, err := h.quizService.UpsertQuiz(c.Request.Context(), chapterID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quiz saved successfully", "data": quiz})
+}
+
+func (h *quizHandlerImpl) DeleteQuiz(c *gin.Context) {
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	if err := h.quizService.DeleteQuiz(c.Request.Context(), chapterID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quiz deleted successfully"})
+}
+
+func (h *quizHandlerImpl) SubmitQuiz(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok || claims == nil {
+		c.Error(apperrors.Unauthorized("User not authenticated or claims not found"))
+		return
+	}
+
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	var req dto.SubmitQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	result, err := h.quizService.SubmitQuiz(c.Request.Context(), claims.UserID, chapterID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Quiz submitted successfully", "data": result})
+}
+
+func (h *quizHandlerImpl) GetMyAttempts(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	history, err := h.quizService.GetAttemptHistory(c.Request.Context(), scope, scope.UserID, chapterID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quiz attempts retrieved successfully", "data": history})
+}
+
+func (h *quizHandlerImpl) GetUserAttempts(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	chapterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid chapter ID format").Wrap(err))
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid user ID format").Wrap(err))
+		return
+	}
+
+	history, err := h.quizService.GetAttemptHistory(c.Request.Context(), scope, userID, chapterID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quiz attempts retrieved successfully", "data": history})
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/service"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportUploadSize bounds the roster upload; a few thousand rows fit in a
+// fraction of this.
+const maxImportUploadSize = 10 << 20
+
+type studentImportHandlerImpl struct {
+	importService service.StudentImportService
+}
+
+func NewStudentImportHandler(importService service.StudentImportService) *studentImportHandlerImpl {
+	return &studentImportHandlerImpl{importService: importService}
+}
+
+func (h *studentImportHandlerImpl) ImportStudents(c *gin.Context) {
+	var query dto.StudentImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUploadSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.Error(apperrors.Validation("A roster file is required").WithField("file", "is required").Wrap(err))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(fmt.Errorf("failed to open uploaded roster: %w", err))
+		return
+	}
+	defer file.Close()
+
+	result, err := h.importService.ImportStudents(c.Request.Context(), file, fileHeader.Filename, &query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	status := http.StatusOK
+	message := "Student import validated successfully"
+	if !query.DryRun {
+		message = fmt.Sprintf("%d student(s) imported", result.Imported)
+		if result.Imported > 0 {
+			status = http.StatusCreated
+		}
+	}
+
+	c.JSON(status, gin.H{"message": message, "data": result})
+}
@@ -1,36 +1,33 @@
 package handler
 
 import (
+	"be-education/apperrors"
 	"be-education/dto"
 	"be-education/models"
 	"be-education/service"
 	"be-education/utils"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 type userHandlerImpl struct {
 	userService service.UserService
-	baseURL     string
 }
 
-func NewUserHandler(userService service.UserService, baseURL string) userHandlerImpl {
-	return userHandlerImpl{userService: userService, baseURL: baseURL}
+func NewUserHandler(userService service.UserService) userHandlerImpl {
+	return userHandlerImpl{userService: userService}
 }
 
 func (h *userHandlerImpl) CreateMahasiswa(c *gin.Context) {
 	var req dto.CreateUserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body or validation failed", "details": err.Error()})
+		c.Error(apperrors.InvalidRequest(err))
 		return
 	}
 
@@ -38,23 +35,14 @@ func (h *userHandlerImpl) CreateMahasiswa(c *gin.Context) {
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: req.Password,
-		Class:    req.Class,
+		ClassID:  req.ClassID,
 		Birthday: req.Birthday,
 		Role:     "mahasiswa",
 	}
 
 	err := h.userService.CreateUser(c.Request.Context(), user)
 	if err != nil {
-		switch err.Error() {
-		case fmt.Sprintf("user with email %s already exists", user.Email):
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-		case "email cannot be empty":
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		case "password cannot be empty for hashing":
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user", "details": err.Error()})
-		}
+		c.Error(err)
 		return
 	}
 
@@ -65,27 +53,30 @@ func (h *userHandlerImpl) Login(c *gin.Context) {
 	var req dto.LoginUserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body or validation failed", "details": err.Error()})
+		c.Error(apperrors.InvalidRequest(err))
 		return
 	}
 
-	token, err := h.userService.Login(c.Request.Context(), req.Email, req.Password)
+	tokens, err := h.userService.Login(c.Request.Context(), req.Email, req.Password, c.ClientIP())
 	if err != nil {
-		if err.Error() == "invalid credentials" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login", "details": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Login successful", "token": token})
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Login successful",
+		"token":              tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"token_type":         tokens.TokenType,
+		"expires_in":         tokens.ExpiresIn,
+		"refresh_expires_in": tokens.RefreshExpiresIn,
+	})
 }
 
 func (h *userHandlerImpl) GetProfile(c *gin.Context) {
 	claims, exists := utils.GetCurrentUserClaims(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User claims not found in context. Authentication required."})
+		c.Error(apperrors.Unauthorized("User claims not found in context. Authentication required."))
 		return
 	}
 
@@ -95,80 +86,136 @@ func (h *userHandlerImpl) GetProfile(c *gin.Context) {
 
 	userDTO, err := h.userService.GetUserByID(ctx, userID)
 	if err != nil {
-		fmt.Printf("Error getting user from service for ID %d: %v\n", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user profile due to internal error"})
+		c.Error(err)
 		return
 	}
 
-	if userDTO == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("User profile with ID %d not found", userID)})
+	c.JSON(http.StatusOK, userDTO)
+}
+
+func (h *userHandlerImpl) UpdateProfile(c *gin.Context) {
+	claims, exists := utils.GetCurrentUserClaims(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User claims not found in context. Authentication required."))
 		return
 	}
 
-	c.JSON(http.StatusOK, userDTO)
+	var req dto.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	userDTO, err := h.userService.UpdateProfile(c.Request.Context(), claims.UserID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully", "data": userDTO})
 }
 
-func (h *userHandlerImpl) UpdateProfileImage(c *gin.Context) {
+func (h *userHandlerImpl) ChangePassword(c *gin.Context) {
 	claims, exists := utils.GetCurrentUserClaims(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User claims not found in context. Authentication required."})
+		c.Error(apperrors.Unauthorized("User claims not found in context. Authentication required."))
 		return
 	}
-	userID := claims.UserID
 
-	file, err := c.FormFile("profile_image")
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	tokens, err := h.userService.ChangePassword(c.Request.Context(), claims.UserID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get profile image file", "details": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	var profileURL string
-	uploadDir := "./uploads/profile_images"
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully. Other sessions have been signed out.", "data": tokens})
+}
 
-	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		err = os.MkdirAll(uploadDir, 0755)
-		if err != nil {
-			log.Printf("Failed to create upload directory %s: %v", uploadDir, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
-			return
-		}
+func (h *userHandlerImpl) UpdateUser(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
 	}
 
-	fileExtension := filepath.Ext(file.Filename)
-	newUUID := uuid.New().String()
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid user ID format").Wrap(err))
+		return
+	}
 
-	cleanedFilename := utils.SanitizeFilename(file.Filename)
-	baseFilename := strings.TrimSuffix(cleanedFilename, fileExtension)
+	var req dto.AdminUpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
 
-	uniqueFileName := fmt.Sprintf("%s_%s%s", newUUID, baseFilename, fileExtension)
+	userDTO, err := h.userService.AdminUpdateUser(c.Request.Context(), scope, userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	filePath := fmt.Sprintf("%s/%s", uploadDir, uniqueFileName)
+	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "data": userDTO})
+}
 
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		log.Printf("Failed to save uploaded file %s: %v", filePath, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save profile image", "details": err.Error()})
+func (h *userHandlerImpl) UpdateProfileImage(c *gin.Context) {
+	claims, exists := utils.GetCurrentUserClaims(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User claims not found in context. Authentication required."))
 		return
 	}
+	userID := claims.UserID
 
-	profileURL = fmt.Sprintf("%s/uploads/profile_images/%s", h.baseURL, uniqueFileName)
+	// Leave room for the multipart envelope; the image itself is checked
+	// against MaxProfileImageSize by the service.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxProfileImageSize+1<<20)
 
-	log.Printf("User %d uploaded file: %s. Stored at: %s. Public URL: %s", userID, file.Filename, filePath, profileURL)
+	file, err := c.FormFile("profile_image")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.Error(apperrors.Validation("Profile image must be at most %d MB", service.MaxProfileImageSize>>20).WithField("profile_image", "is too large"))
+			return
+		}
+		c.Error(apperrors.Validation("Failed to get profile image file").WithField("profile_image", "is required").Wrap(err))
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.Error(fmt.Errorf("failed to open uploaded file: %w", err))
+		return
+	}
+	defer src.Close()
 
-	err = h.userService.UpdateProfileURL(c.Request.Context(), userID, profileURL)
+	profileImage, err := h.userService.UpdateProfileImage(c.Request.Context(), userID, src)
 	if err != nil {
-		log.Printf("Error updating profile URL for user %d: %v", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile image URL", "details": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile image updated successfully", "profile_url": profileURL})
+	slog.InfoContext(c.Request.Context(), "Profile image uploaded", "filename", file.Filename, "url", profileImage.Original)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile image updated successfully", "data": profileImage})
 }
 
 func (h *userHandlerImpl) GetStudentSummary(c *gin.Context) {
-	summary, err := h.userService.GetOverallStudentSummary(c.Request.Context())
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	summary, err := h.userService.GetOverallStudentSummary(c.Request.Context(), scope)
 	if err != nil {
-		log.Printf("Error getting student summary from service: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get student summary"})
+		c.Error(err)
 		return
 	}
 
@@ -179,7 +226,7 @@ func (h *userHandlerImpl) CreateAdmin(c *gin.Context) {
 	var req dto.CreateUserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body or validation failed", "details": err.Error()})
+		c.Error(apperrors.InvalidRequest(err))
 		return
 	}
 
@@ -187,73 +234,194 @@ func (h *userHandlerImpl) CreateAdmin(c *gin.Context) {
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: req.Password,
-		Class:    req.Class,
+		ClassID:  req.ClassID,
 		Birthday: req.Birthday,
 	}
 
 	err := h.userService.CreateAdmin(c.Request.Context(), user)
 	if err != nil {
-		switch err.Error() {
-		case fmt.Sprintf("user with email %s not found", user.Email):
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		case fmt.Sprintf("user with email %s already exists", user.Email):
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-		case "email cannot be empty":
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		case "password cannot be empty for hashing":
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		default:
-			log.Printf("Error creating admin user: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create admin user", "details": err.Error()})
-		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Admin user created successfully"})
+}
+
+func (h *userHandlerImpl) CreateGuru(c *gin.Context) {
+	var req dto.CreateUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	user := &models.User{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+		Birthday: req.Birthday,
+	}
+
+	err := h.userService.CreateGuru(c.Request.Context(), user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Guru user created successfully"})
+}
+
+func (h *userHandlerImpl) SetupAdmin(c *gin.Context) {
+	var req dto.CreateUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	user := &models.User{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+		ClassID:  req.ClassID,
+		Birthday: req.Birthday,
+	}
+
+	err := h.userService.BootstrapAdmin(c.Request.Context(), user)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
+	slog.InfoContext(c.Request.Context(), "First admin created through setup endpoint", "admin_id", user.ID)
 	c.JSON(http.StatusCreated, gin.H{"message": "Admin user created successfully"})
 }
 
 func (h *userHandlerImpl) GetAdminSummary(c *gin.Context) {
-	summary, err := h.userService.GetAdminSummary(c.Request.Context())
+	var query dto.ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	summary, err := h.userService.GetAdminSummary(c.Request.Context(), &query)
 	if err != nil {
-		log.Printf("Error getting admin summary from service: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get admin summary"})
+		c.Error(err)
 		return
 	}
 
+	setPaginationHeaders(c, summary.Pagination)
 	c.JSON(http.StatusOK, summary)
 }
 
 func (h *userHandlerImpl) GetMahasiswaUsers(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
 
-	mahasiswaUsers, err := h.userService.GetMahasiswaUsers(c.Request.Context())
+	var query dto.ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	mahasiswaUsers, err := h.userService.GetMahasiswaUsers(c.Request.Context(), scope, &query)
 	if err != nil {
-		log.Printf("Error getting mahasiswa users from service: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get mahasiswa users"})
+		c.Error(err)
 		return
 	}
 
+	setPaginationHeaders(c, mahasiswaUsers.Pagination)
 	c.JSON(http.StatusOK, mahasiswaUsers)
 }
 
 func (h *userHandlerImpl) DeleteUser(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
 	idParam := c.Param("id")
 	userID, err := strconv.ParseInt(idParam, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format", "details": err.Error()})
+		c.Error(apperrors.Validation("Invalid user ID format").Wrap(err))
 		return
 	}
 
 	ctx := c.Request.Context()
 
-	err = h.userService.DeleteUser(ctx, userID)
+	err = h.userService.DeleteUser(ctx, scope, userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("User with ID %d not found", userID)})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user", "details": err.Error()})
-		}
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("User with ID %d deleted successfully", userID)})
 }
+
+func (h *userHandlerImpl) DeactivateUser(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid user ID format").Wrap(err))
+		return
+	}
+
+	if err := h.userService.DeactivateUser(c.Request.Context(), scope, userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("User with ID %d deactivated successfully", userID)})
+}
+
+func (h *userHandlerImpl) RestoreUser(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid user ID format").Wrap(err))
+		return
+	}
+
+	userDTO, err := h.userService.RestoreUser(c.Request.Context(), scope, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User restored successfully", "data": userDTO})
+}
+
+func (h *userHandlerImpl) UnlockUser(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperrors.Validation("Invalid user ID format").Wrap(err))
+		return
+	}
+
+	if err := h.userService.UnlockUser(c.Request.Context(), scope, userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("User with ID %d unlocked successfully", userID)})
+}
@@ -1,36 +1,40 @@
 package handler
 
 import (
+	"be-education/apperrors"
 	"be-education/dto"
+	"be-education/export"
 	"be-education/models"
 	"be-education/service"
 	"be-education/utils"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type userChapterHandlerImpl struct {
 	userChapterService service.UserChapterService
+	classService       service.ClassService
 }
 
-func NewUserChapterHandler(userChapterService service.UserChapterService) *userChapterHandlerImpl {
-	return &userChapterHandlerImpl{userChapterService: userChapterService}
+func NewUserChapterHandler(userChapterService service.UserChapterService, classService service.ClassService) *userChapterHandlerImpl {
+	return &userChapterHandlerImpl{userChapterService: userChapterService, classService: classService}
 }
 
 func (h *userChapterHandlerImpl) CreateUserChapter(c *gin.Context) {
 	var req dto.CreateUserChapterRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body or validation failed", "details": err.Error()})
+		c.Error(apperrors.InvalidRequest(err))
 		return
 	}
 
 	claims, ok := utils.GetCurrentUserClaims(c)
 	if !ok || claims == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated or claims not found"})
+		c.Error(apperrors.Unauthorized("User not authenticated or claims not found"))
 		return
 	}
 
@@ -38,19 +42,11 @@ func (h *userChapterHandlerImpl) CreateUserChapter(c *gin.Context) {
 		UserID:      claims.UserID,
 		ChapterID:   req.ChapterID,
 		CompletedAt: req.CompletedAt,
-		QuizScore:   req.QuizScore,
 	}
 
 	err := h.userChapterService.CreateUserChapter(c.Request.Context(), userChapter)
 	if err != nil {
-		switch err.Error() {
-		case "user ID cannot be zero":
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Authenticated user ID is invalid"})
-		case "chapter ID cannot be zero":
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user chapter", "details": err.Error()})
-		}
+		c.Error(err)
 		return
 	}
 
@@ -60,13 +56,13 @@ func (h *userChapterHandlerImpl) CreateUserChapter(c *gin.Context) {
 func (h *userChapterHandlerImpl) GetUserQuizScores(c *gin.Context) {
 	claims, ok := utils.GetCurrentUserClaims(c)
 	if !ok || claims == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated or claims not found"})
+		c.Error(apperrors.Unauthorized("User not authenticated or claims not found"))
 		return
 	}
 
 	quizScores, err := h.userChapterService.GetUserQuizScoresByUserID(c.Request.Context(), claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user quiz scores", "details": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -77,27 +73,27 @@ func (h *userChapterHandlerImpl) CheckUserChapterCompletion(c *gin.Context) {
 	var req dto.CheckChapterCompletionRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body or validation failed", "details": err.Error()})
+		c.Error(apperrors.InvalidRequest(err))
 		return
 	}
 
 	claims, ok := utils.GetCurrentUserClaims(c)
 	if !ok || claims == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated or claims not found"})
+		c.Error(apperrors.Unauthorized("User not authenticated or claims not found"))
 		return
 	}
 	userID := claims.UserID
 	chapterID := req.ChapterID
 
 	if chapterID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Chapter ID must be a positive integer"})
+		c.Error(apperrors.Validation("Chapter ID must be a positive integer").WithField("chapter_id", "must be a positive integer"))
 		return
 	}
 
 	ctx := c.Request.Context()
 	isCompleted, err := h.userChapterService.CheckUserChapterCompleted(ctx, userID, chapterID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check chapter completion status", "details": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -117,13 +113,62 @@ func (h *userChapterHandlerImpl) CheckUserChapterCompletion(c *gin.Context) {
 }
 
 func (h *userChapterHandlerImpl) GetAllUsersChapterScores(c *gin.Context) {
+	scope, exists := utils.GetCurrentScope(c)
+	if !exists {
+		c.Error(apperrors.Unauthorized("User scope not found in context. Authentication required."))
+		return
+	}
+
+	var query dto.GradebookQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	var class *models.Class
+	if query.ClassID != 0 {
+		var err error
+		class, err = h.classService.GetClassByID(c.Request.Context(), scope, query.ClassID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	if query.Format != "" && query.Format != "json" {
+		h.exportChapterScores(c, scope, query.Format, class)
+		return
+	}
 
-	summary, err := h.userChapterService.GetAllUsersChapterScoresSummary(c.Request.Context())
+	summary, err := h.userChapterService.GetAllUsersChapterScoresSummary(c.Request.Context(), scope, query.ClassID)
 	if err != nil {
-		log.Printf("Error getting all users chapter scores summary: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user chapter scores summary"})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, summary)
 }
+
+func (h *userChapterHandlerImpl) exportChapterScores(c *gin.Context, scope *models.Scope, format string, class *models.Class) {
+	filename := "gradebook-" + time.Now().Format("20060102")
+	if class != nil {
+		filename += "-" + utils.SanitizeFilename(class.Name)
+	}
+	filename += "." + format
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	err := h.userChapterService.ExportGradebook(c.Request.Context(), scope, c.Writer, format, class)
+	if err != nil {
+		if c.Writer.Written() {
+			// Headers and part of the file are already on the wire; all we
+			// can do is log and cut the download short.
+			slog.ErrorContext(c.Request.Context(), "Gradebook export aborted mid-stream", "error", err)
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		c.Error(err)
+	}
+}
@@ -0,0 +1,173 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	// Registered for image.Decode.
+	_ "image/gif"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrFileTooLarge      = errors.New("image file is too large")
+	ErrTooManyPixels     = errors.New("image dimensions are too large")
+)
+
+// allowedTypes are the sniffed content types accepted for upload.
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+const jpegQuality = 85
+
+// Limits bound what Process accepts. Pixel limits are checked against the
+// image header before the image is decoded, so an oversized image never
+// gets allocated.
+type Limits struct {
+	MaxBytes     int64
+	MaxPixels    int
+	MaxDimension int
+}
+
+// Encoded is a re-encoded image ready to be stored.
+type Encoded struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// Variant is a square thumbnail of Size x Size pixels.
+type Variant struct {
+	Size int
+	Encoded
+}
+
+// Result holds the cleaned original and its thumbnails.
+type Result struct {
+	Original Encoded
+	Variants []Variant
+}
+
+// Process validates an uploaded image by its content rather than its file
+// name, applies the EXIF orientation and re-encodes it, which drops all
+// metadata (EXIF, GPS, ICC comments). A square thumbnail is generated for
+// every size in sizes. Opaque images are encoded as JPEG, images with
+// transparency as PNG.
+func Process(r io.Reader, limits Limits, sizes []int) (*Result, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limits.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, ErrUnsupportedFormat
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if config.Width <= 0 || config.Height <= 0 ||
+		config.Width > limits.MaxDimension || config.Height > limits.MaxDimension ||
+		config.Width*config.Height > limits.MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	img := toNRGBA(decoded)
+	if contentType == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	opaque := img.Opaque()
+
+	original, err := encode(img, opaque)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Original: *original}
+	for _, size := range sizes {
+		encoded, err := encode(thumbnail(img, size), opaque)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, Variant{Size: size, Encoded: *encoded})
+	}
+	return result, nil
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if img, ok := src.(*image.NRGBA); ok && img.Bounds().Min == (image.Point{}) {
+		return img
+	}
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+	return dst
+}
+
+// thumbnail scales the image to cover a size x size square and crops the
+// overflow around the center.
+func thumbnail(src *image.NRGBA, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	crop := bounds
+	if w > h {
+		offset := (w - h) / 2
+		crop = image.Rect(offset, 0, offset+h, h)
+	} else if h > w {
+		offset := (h - w) / 2
+		crop = image.Rect(0, offset, w, offset+w)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, xdraw.Src, nil)
+	return dst
+}
+
+func encode(img *image.NRGBA, opaque bool) (*Encoded, error) {
+	var buf bytes.Buffer
+	encoded := &Encoded{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	if opaque {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+		encoded.ContentType, encoded.Extension = "image/jpeg", ".jpg"
+	} else {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := encoder.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode PNG: %w", err)
+		}
+		encoded.ContentType, encoded.Extension = "image/png", ".png"
+	}
+
+	encoded.Data = buf.Bytes()
+	return encoded, nil
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Every runs fn once per interval until ctx is canceled. The first run
+// happens one interval after start. Errors are logged and do not stop the
+// schedule; a non-positive interval disables the job.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	if interval <= 0 {
+		slog.InfoContext(ctx, "Job disabled", "job", name)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := fn(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.ErrorContext(ctx, "Job failed", "job", name, "duration", time.Since(start).Round(time.Millisecond), "error", err)
+				continue
+			}
+			slog.InfoContext(ctx, "Job finished", "job", name, "duration", time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package loginguard slows down password guessing. Failed logins are counted
+// per client IP and per account: every failure makes the next attempt wait
+// exponentially longer, and an account that keeps failing is locked for a
+// while, until it expires or an admin unlocks it.
+package loginguard
+
+import (
+	"be-education/config"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Policy holds the thresholds of a Guard.
+type Policy struct {
+	// MaxFailures failed attempts within Window lock the account for
+	// Lockout.
+	MaxFailures int
+	Window      time.Duration
+	Lockout     time.Duration
+	// IPMaxFailures failed attempts from one IP are allowed before the IP is
+	// slowed down too. It is higher than MaxFailures because schools share
+	// NAT addresses.
+	IPMaxFailures int
+	// The n-th backed-off failure delays the next attempt by
+	// BackoffBase * 2^(n-1), at most BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// Block tells why and for how long an attempt is refused.
+type Block struct {
+	RetryAfter time.Duration
+	// Locked is set when the account itself is locked, as opposed to the
+	// caller merely having to slow down.
+	Locked bool
+}
+
+type Guard struct {
+	store  Store
+	policy Policy
+}
+
+func NewGuard(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy}
+}
+
+// New returns the Guard configured by cfg.LoginGuard, backed by the store
+// named in its Store field: "memory", "redis" or "miniredis" (the Redis store
+// on an embedded stand-in server).
+func New(ctx context.Context, cfg *config.Config) (*Guard, error) {
+	var store Store
+	var err error
+
+	switch cfg.LoginGuard.Store {
+	case "memory", "":
+		store = NewMemoryStore()
+	case "redis":
+		if cfg.LoginGuard.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL must be set for the redis login guard store")
+		}
+		store, err = NewRedisStore(ctx, cfg.LoginGuard.RedisURL)
+	case "miniredis":
+		store, err = NewEmbeddedRedisStore()
+	default:
+		return nil, fmt.Errorf("unknown login guard store %q", cfg.LoginGuard.Store)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGuard(store, Policy{
+		MaxFailures:   cfg.LoginGuard.MaxFailures,
+		Window:        cfg.LoginGuard.Window,
+		Lockout:       cfg.LoginGuard.Lockout,
+		IPMaxFailures: cfg.LoginGuard.IPMaxFailures,
+		BackoffBase:   cfg.LoginGuard.BackoffBase,
+		BackoffMax:    cfg.LoginGuard.BackoffMax,
+	}), nil
+}
+
+func (g *Guard) Close() error {
+	return g.store.Close()
+}
+
+// Check returns a non-nil Block when the attempt must be refused without
+// looking at the password.
+func (g *Guard) Check(ctx context.Context, ip, account string) (*Block, error) {
+	account = normalizeAccount(account)
+
+	locked, err := g.store.Blocked(ctx, lockKey(account))
+	if err != nil {
+		return nil, err
+	}
+	if locked > 0 {
+		return &Block{RetryAfter: locked, Locked: true}, nil
+	}
+
+	wait, err := g.store.Blocked(ctx, "wait:account:"+account)
+	if err != nil {
+		return nil, err
+	}
+	ipWait, err := g.store.Blocked(ctx, "wait:ip:"+ip)
+	if err != nil {
+		return nil, err
+	}
+	wait = max(wait, ipWait)
+	if wait > 0 {
+		return &Block{RetryAfter: wait}, nil
+	}
+	return nil, nil
+}
+
+// Fail records a failed attempt and applies the resulting backoff or lock.
+// It reports whether the account got locked by this failure.
+func (g *Guard) Fail(ctx context.Context, ip, account string) (bool, error) {
+	account = normalizeAccount(account)
+	locked := false
+
+	failures, err := g.store.Incr(ctx, "failures:account:"+account, g.policy.Window)
+	if err != nil {
+		return false, err
+	}
+	if g.policy.MaxFailures > 0 && failures >= int64(g.policy.MaxFailures) {
+		if err := g.store.Block(ctx, lockKey(account), g.policy.Lockout); err != nil {
+			return false, err
+		}
+		// Start over once the lock expires.
+		if err := g.store.Delete(ctx, "failures:account:"+account); err != nil {
+			return false, err
+		}
+		locked = true
+	} else if delay := g.backoff(failures); delay > 0 {
+		if err := g.store.Block(ctx, "wait:account:"+account, delay); err != nil {
+			return false, err
+		}
+	}
+
+	ipFailures, err := g.store.Incr(ctx, "failures:ip:"+ip, g.policy.Window)
+	if err != nil {
+		return locked, err
+	}
+	if excess := ipFailures - int64(g.policy.IPMaxFailures); excess > 0 {
+		if err := g.store.Block(ctx, "wait:ip:"+ip, g.backoff(excess)); err != nil {
+			return locked, err
+		}
+	}
+	return locked, nil
+}
+
+// Succeed clears the account's failures after a successful login. The IP's
+// failures are kept, so an attacker cannot reset them by also signing in to
+// an account of their own.
+func (g *Guard) Succeed(ctx context.Context, account string) error {
+	account = normalizeAccount(account)
+	return g.store.Delete(ctx, "failures:account:"+account, "wait:account:"+account)
+}
+
+// Unlock lifts a lock and clears the failures of the account.
+func (g *Guard) Unlock(ctx context.Context, account string) error {
+	account = normalizeAccount(account)
+	return g.store.Delete(ctx, lockKey(account), "failures:account:"+account, "wait:account:"+account)
+}
+
+func (g *Guard) backoff(n int64) time.Duration {
+	if n <= 0 || g.policy.BackoffBase <= 0 {
+		return 0
+	}
+	// Double step by step and stop at the cap: shifting BackoffBase by n-1
+	// overflows into negative delays long before n gets large.
+	delay := g.policy.BackoffBase
+	for i := int64(1); i < n && delay < g.policy.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, g.policy.BackoffMax)
+}
+
+func lockKey(account string) string {
+	return "lock:account:" + account
+}
+
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
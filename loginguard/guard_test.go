@@ -0,0 +1,255 @@
+package loginguard
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+const testIP = "203.0.113.7"
+
+// stores lists the Store implementations every Guard test runs against.
+var stores = []struct {
+	name string
+	open func(t *testing.T) Store
+}{
+	{"memory", func(t *testing.T) Store { return NewMemoryStore() }},
+	{"miniredis", func(t *testing.T) Store {
+		store, err := NewEmbeddedRedisStore()
+		if err != nil {
+			t.Fatalf("NewEmbeddedRedisStore: %v", err)
+		}
+		return store
+	}},
+}
+
+// forEachStore runs fn once per Store with a Guard enforcing policy.
+func forEachStore(t *testing.T, policy Policy, fn func(t *testing.T, g *Guard)) {
+	t.Helper()
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			g := NewGuard(s.open(t), policy)
+			t.Cleanup(func() { g.Close() })
+			fn(t, g)
+		})
+	}
+}
+
+func fail(t *testing.T, g *Guard, ip, account string, times int) bool {
+	t.Helper()
+	locked := false
+	for i := 0; i < times; i++ {
+		var err error
+		if locked, err = g.Fail(context.Background(), ip, account); err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+	}
+	return locked
+}
+
+func check(t *testing.T, g *Guard, ip, account string) *Block {
+	t.Helper()
+	block, err := g.Check(context.Background(), ip, account)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	return block
+}
+
+// assertRetryAfter allows for the time spent between blocking and checking.
+func assertRetryAfter(t *testing.T, block *Block, want time.Duration, wantLocked bool) {
+	t.Helper()
+	if block == nil {
+		t.Fatalf("Check = nil, want a block of %s", want)
+	}
+	if block.Locked != wantLocked {
+		t.Errorf("Locked = %v, want %v", block.Locked, wantLocked)
+	}
+	if block.RetryAfter > want || block.RetryAfter < want-time.Second/2 {
+		t.Errorf("RetryAfter = %s, want about %s", block.RetryAfter, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name string
+		base time.Duration
+		max  time.Duration
+		n    int64
+		want time.Duration
+	}{
+		{"no failures", time.Second, time.Minute, 0, 0},
+		{"first failure", time.Second, time.Minute, 1, time.Second},
+		{"second failure", time.Second, time.Minute, 2, 2 * time.Second},
+		{"sixth failure", time.Second, time.Minute, 6, 32 * time.Second},
+		{"capped", time.Second, time.Minute, 7, time.Minute},
+		{"shift would overflow", time.Second, time.Minute, 64, time.Minute},
+		{"largest count", time.Second, time.Minute, math.MaxInt64, time.Minute},
+		{"base above max", time.Minute, time.Second, 1, time.Second},
+		{"disabled", 0, time.Minute, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGuard(nil, Policy{BackoffBase: tt.base, BackoffMax: tt.max})
+			if got := g.backoff(tt.n); got != tt.want {
+				t.Errorf("backoff(%d) = %s, want %s", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardLockout(t *testing.T) {
+	policy := Policy{
+		MaxFailures:   3,
+		Window:        time.Minute,
+		Lockout:       10 * time.Minute,
+		IPMaxFailures: 5,
+	}
+	tests := []struct {
+		name       string
+		failures   int
+		wantLocked bool
+		// wantIPWait is the backoff other accounts see from the same IP.
+		wantIPWait bool
+	}{
+		{"below the account threshold", 2, false, false},
+		{"at the account threshold", 3, true, false},
+		{"beyond the ip threshold", 6, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := policy
+			if tt.wantIPWait {
+				p.BackoffBase, p.BackoffMax = time.Minute, time.Hour
+			}
+			forEachStore(t, p, func(t *testing.T, g *Guard) {
+				locked := fail(t, g, testIP, "student@example.com", tt.failures)
+				if locked != tt.wantLocked {
+					t.Errorf("Fail locked = %v, want %v", locked, tt.wantLocked)
+				}
+
+				block := check(t, g, testIP, " Student@Example.com ")
+				if tt.wantLocked {
+					assertRetryAfter(t, block, p.Lockout, true)
+				} else if block != nil {
+					t.Errorf("Check = %+v, want nil", block)
+				}
+
+				other := check(t, g, testIP, "other@example.com")
+				if tt.wantIPWait {
+					assertRetryAfter(t, other, p.BackoffBase, false)
+				} else if other != nil {
+					t.Errorf("Check for another account = %+v, want nil", other)
+				}
+			})
+		})
+	}
+}
+
+func TestGuardBackoffSequence(t *testing.T) {
+	policy := Policy{
+		MaxFailures:   10,
+		Window:        time.Minute,
+		Lockout:       time.Minute,
+		IPMaxFailures: 100,
+		BackoffBase:   time.Second,
+		BackoffMax:    5 * time.Second,
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+
+	forEachStore(t, policy, func(t *testing.T, g *Guard) {
+		for i, delay := range want {
+			if locked := fail(t, g, testIP, "student@example.com", 1); locked {
+				t.Fatalf("failure %d locked the account", i+1)
+			}
+			assertRetryAfter(t, check(t, g, testIP, "student@example.com"), delay, false)
+		}
+	})
+}
+
+func TestGuardWindowExpiry(t *testing.T) {
+	policy := Policy{
+		MaxFailures:   2,
+		Window:        300 * time.Millisecond,
+		Lockout:       time.Minute,
+		IPMaxFailures: 100,
+	}
+
+	forEachStore(t, policy, func(t *testing.T, g *Guard) {
+		fail(t, g, testIP, "student@example.com", 1)
+		time.Sleep(600 * time.Millisecond)
+
+		if locked := fail(t, g, testIP, "student@example.com", 1); locked {
+			t.Error("a failure outside the window counted towards the lock")
+		}
+		if locked := fail(t, g, testIP, "student@example.com", 1); !locked {
+			t.Error("two failures within the window did not lock the account")
+		}
+	})
+}
+
+func TestGuardResets(t *testing.T) {
+	policy := Policy{
+		MaxFailures:   3,
+		Window:        time.Minute,
+		Lockout:       time.Minute,
+		IPMaxFailures: 100,
+		BackoffBase:   time.Second,
+		BackoffMax:    time.Minute,
+	}
+	tests := []struct {
+		name     string
+		failures int
+		reset    func(g *Guard, account string) error
+	}{
+		{"succeed clears failures and backoff", 2, func(g *Guard, account string) error {
+			return g.Succeed(context.Background(), account)
+		}},
+		{"unlock lifts the lock", 3, func(g *Guard, account string) error {
+			return g.Unlock(context.Background(), account)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, policy, func(t *testing.T, g *Guard) {
+				fail(t, g, testIP, "student@example.com", tt.failures)
+				if check(t, g, testIP, "student@example.com") == nil {
+					t.Fatal("Check = nil before the reset, want a block")
+				}
+
+				if err := tt.reset(g, "Student@example.com"); err != nil {
+					t.Fatalf("reset: %v", err)
+				}
+				if block := check(t, g, testIP, "student@example.com"); block != nil {
+					t.Errorf("Check after the reset = %+v, want nil", block)
+				}
+				// The count starts over, so the next failure backs off
+				// by the base delay again.
+				if locked := fail(t, g, testIP, "student@example.com", 1); locked {
+					t.Error("the first failure after the reset locked the account")
+				}
+				assertRetryAfter(t, check(t, g, testIP, "student@example.com"), policy.BackoffBase, false)
+			})
+		})
+	}
+}
+
+func TestGuardSucceedKeepsIPFailures(t *testing.T) {
+	policy := Policy{
+		MaxFailures:   10,
+		Window:        time.Minute,
+		Lockout:       time.Minute,
+		IPMaxFailures: 2,
+		BackoffBase:   time.Minute,
+		BackoffMax:    time.Hour,
+	}
+
+	forEachStore(t, policy, func(t *testing.T, g *Guard) {
+		fail(t, g, testIP, "student@example.com", 3)
+		if err := g.Succeed(context.Background(), "student@example.com"); err != nil {
+			t.Fatalf("Succeed: %v", err)
+		}
+		assertRetryAfter(t, check(t, g, testIP, "student@example.com"), policy.BackoffBase, false)
+	})
+}
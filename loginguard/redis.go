@@ -0,0 +1,129 @@
+package loginguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "loginguard:"
+
+// RedisStore keeps counters in Redis or any server speaking its protocol
+// (Valkey, KeyDB, Dragonfly), so every instance of a cluster shares them.
+type RedisStore struct {
+	client *redis.Client
+	// embedded is the in-process stand-in server, if any.
+	embedded *miniredis.Miniredis
+	stop     chan struct{}
+}
+
+// NewRedisStore connects to the server at url, e.g.
+// "redis://:password@localhost:6379/0".
+func NewRedisStore(ctx context.Context, url string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// NewEmbeddedRedisStore runs the Redis store against an in-process stand-in
+// server. It exercises the same code path as a real Redis for local
+// development and CI, but like MemoryStore it only protects one instance.
+func NewEmbeddedRedisStore() (*RedisStore, error) {
+	server, err := miniredis.Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start embedded redis: %w", err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	store := &RedisStore{client: client, embedded: server, stop: make(chan struct{})}
+	go store.advanceEmbeddedClock()
+	return store, nil
+}
+
+// advanceEmbeddedClock lets keys of the stand-in server expire: miniredis
+// was built for tests and only counts TTLs down when told to.
+func (s *RedisStore) advanceEmbeddedClock() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.embedded.FastForward(now.Sub(last))
+			last = now
+		}
+	}
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	key = redisKeyPrefix + key
+
+	// SET NX starts the counter with its expiry; INCR keeps the expiry of an
+	// existing key. MULTI makes the pair atomic.
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, 0, ttl)
+		incr = pipe.Incr(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment %s: %w", key, err)
+	}
+	return incr.Val(), nil
+}
+
+func (s *RedisStore) Block(ctx context.Context, key string, d time.Duration) error {
+	if err := s.client.Set(ctx, redisKeyPrefix+key, 1, d).Err(); err != nil {
+		return fmt.Errorf("failed to block %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Blocked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, redisKeyPrefix+key).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("failed to check block %s: %w", key, err)
+	}
+	// PTTL reports negative values for missing keys and keys without expiry.
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = redisKeyPrefix + key
+	}
+	if err := s.client.Del(ctx, prefixed...).Err(); err != nil {
+		return fmt.Errorf("failed to delete login guard keys: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	err := s.client.Close()
+	if s.embedded != nil {
+		close(s.stop)
+		s.embedded.Close()
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps failure counters and blocks. Implementations must be safe for
+// concurrent use; clusters need a store shared by every instance.
+type Store interface {
+	// Incr adds one to the counter at key and returns the new value. A new
+	// counter expires after ttl; incrementing does not extend it.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Block marks key as blocked for d, replacing any earlier block.
+	Block(ctx context.Context, key string, d time.Duration) error
+	// Blocked returns how long key stays blocked, or 0 if it is not.
+	Blocked(ctx context.Context, key string) (time.Duration, error)
+	// Delete removes the keys, whether counters or blocks.
+	Delete(ctx context.Context, keys ...string) error
+	Close() error
+}
+
+type memoryEntry struct {
+	count   int64
+	expires time.Time
+}
+
+// MemoryStore keeps everything in process memory. It only protects a single
+// instance; counters are lost on restart.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), lastSweep: time.Now()}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		entry = memoryEntry{expires: now.Add(ttl)}
+	}
+	entry.count++
+	s.entries[key] = entry
+	return entry.count, nil
+}
+
+func (s *MemoryStore) Block(ctx context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{count: 1, expires: time.Now().Add(d)}
+	return nil
+}
+
+func (s *MemoryStore) Blocked(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	remaining := time.Until(entry.expires)
+	if remaining <= 0 {
+		delete(s.entries, key)
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// sweep drops expired entries at most once a minute so keys of attackers
+// that gave up do not pile up. It must be called with s.mu held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
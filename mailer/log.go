@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"be-education/utils"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// logMailer is meant for local development: it prints every message to the
+// application log and, when dir is set, also writes it to a .eml-like file.
+type logMailer struct {
+	dir string
+}
+
+func NewLogMailer(dir string) Mailer {
+	return &logMailer{dir: dir}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg Message) error {
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	slog.InfoContext(ctx, "Mail (log driver)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mail log directory %s: %w", m.dir, err)
+	}
+
+	fileName := fmt.Sprintf("%d_%s.txt", time.Now().UnixNano(), utils.SanitizeFilename(msg.To))
+	if err := os.WriteFile(filepath.Join(m.dir, fileName), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write mail to log directory: %w", err)
+	}
+	return nil
+}
@@ -3,9 +3,20 @@ package main
 import (
 	"be-education/config"
 	"be-education/db"
+	"be-education/jobs"
+	"be-education/logging"
+	"be-education/loginguard"
+	"be-education/mailer"
+	"be-education/metrics"
+	"be-education/middleware"
+	"be-education/repository"
 	"be-education/router"
+	"be-education/service"
+	"be-education/storage"
+	"be-education/utils"
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -16,27 +27,141 @@ import (
 	"github.com/joho/godotenv"
 )
 
+const usage = `Usage: be-education [command]
+
+Commands:
+  serve                  Start the HTTP server (default)
+  migrate up             Apply all pending migrations
+  migrate down [n|all]   Roll back the last n migrations (default 1)
+  migrate status         Show applied and pending migrations
+  create-admin           Create an admin account
+                           -name, -email, -password
+                           (or ADMIN_NAME, ADMIN_EMAIL, ADMIN_PASSWORD)
+  gc-images [-dry-run]   Delete stored profile images no user references
+
+Settings are read from the environment (and .env). APP_CONFIG_FILE may name
+a YAML or TOML file with defaults that the environment overrides.`
+
 func main() {
 	err := godotenv.Load()
+	// The mode may come from .env, so logging is set up after loading it.
+	logging.Setup(os.Getenv("APP_SERVER_MODE"))
 	if err != nil {
-		log.Println("Peringatan: File .env tidak ditemukan atau tidak dapat dimuat. Menggunakan variabel lingkungan sistem.")
+		slog.Warn("No usable .env file, using system environment variables", "error", err)
 	}
 
-	cfg := config.LoadConfig()
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "serve":
+		runServer()
+	case "migrate":
+		os.Exit(runMigrate(os.Args[2:]))
+	case "create-admin":
+		os.Exit(runCreateAdmin(os.Args[2:]))
+	case "gc-images":
+		os.Exit(runGCImages(os.Args[2:]))
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s\n", command, usage)
+		os.Exit(2)
+	}
+}
+
+func runServer() {
+	cfg, ok := loadConfig()
+	if !ok {
+		os.Exit(1)
+	}
 
 	gin.SetMode(cfg.Server.Mode)
 
 	dbConn, err := db.Connect(cfg)
 	if err != nil {
-		log.Fatalf("Gagal terhubung ke database: %v", err)
+		fatal("Failed to connect to the database", err)
 	}
 	defer func() {
 		if err := db.Close(dbConn); err != nil {
-			log.Printf("Gagal menutup koneksi database: %v", err)
+			slog.Error("Failed to close the database connection", "error", err)
 		}
 	}()
 
-	r := router.InitRouter(dbConn, cfg)
+	if cfg.Metrics.Enabled() {
+		metrics.RegisterDB("postgres", dbConn.DB)
+	}
+
+	migrator, err := db.NewMigrator(dbConn)
+	if err != nil {
+		fatal("Failed to load migrations", err)
+	}
+	if cfg.DBConfig.AutoMigrate {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			fatal("Failed to apply migrations", err)
+		}
+		slog.Info("Auto-migrate finished", "applied", applied)
+	}
+
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		fatal("Failed to set up the mailer", err)
+	}
+
+	files, err := storage.New(cfg)
+	if err != nil {
+		fatal("Failed to set up file storage", err)
+	}
+
+	loginGuard, err := loginguard.New(context.Background(), cfg)
+	if err != nil {
+		fatal("Failed to set up the login guard", err)
+	}
+	defer func() {
+		if err := loginGuard.Close(); err != nil {
+			slog.Error("Failed to close the login guard", "error", err)
+		}
+	}()
+
+	healthService := service.NewHealthService(dbConn, files, migrator, cfg.Server.HealthCheckTimeout)
+	r := router.InitRouter(dbConn, cfg, mail, files, loginGuard, healthService)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	userRepo := repository.NewUserRepository(dbConn)
+	tokenService := service.NewTokenService(repository.NewTokenRepository(dbConn), userRepo, utils.NewJWTUtil(cfg.SecretKey, cfg.Token.AccessTTL), cfg.Token.RefreshTTL)
+	permissionService := service.NewPermissionService(repository.NewPermissionRepository(dbConn), cfg.PermissionCacheTTL)
+	userService := service.NewUserService(userRepo, repository.NewClassRepository(dbConn), tokenService, permissionService, files, loginGuard)
+
+	imageGC := service.NewProfileImageGCService(userRepo, files, cfg.ImageGC.GracePeriod)
+	go jobs.Every(jobsCtx, "profile image GC", cfg.ImageGC.Interval, func(ctx context.Context) error {
+		report, err := imageGC.Sweep(ctx, false)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Profile image GC finished", "scanned", report.Scanned, "deleted", report.Deleted, "freed_bytes", report.FreedBytes, "failed", len(report.Errors))
+		return nil
+	})
+	go jobs.Every(jobsCtx, "deleted user purge", cfg.UserPurge.Interval, func(ctx context.Context) error {
+		purged, err := userService.PurgeDeletedUsers(ctx, cfg.UserPurge.Retention)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Deleted user purge finished", "purged", purged)
+		return nil
+	})
+	go jobs.Every(jobsCtx, "expired token purge", cfg.Token.PurgeInterval, func(ctx context.Context) error {
+		purged, err := tokenService.PurgeExpiredTokens(ctx)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Expired token purge finished", "purged", purged)
+		return nil
+	})
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
@@ -44,24 +169,77 @@ func main() {
 	}
 
 	go func() {
-		log.Printf("Memulai server di %s", cfg.Server.BaseURL)
+		slog.Info("Starting server", "addr", srv.Addr, "base_url", cfg.Server.BaseURL)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Gagal memulai server: %v", err)
+			fatal("Failed to start the server", err)
 		}
 	}()
 
+	var metricsSrv *http.Server
+	if cfg.Metrics.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", middleware.MetricsHandler(cfg.Metrics.Token))
+		metricsSrv = &http.Server{Addr: cfg.Metrics.Addr, Handler: mux}
+		go func() {
+			slog.Info("Starting metrics server", "addr", metricsSrv.Addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Failed to start the metrics server", err)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Menerima sinyal shutdown. Mematikan server...")
+	slog.Info("Shutdown signal received, stopping server")
+
+	stopJobs()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Report not-ready first and keep serving for the drain delay, so load
+	// balancers move new traffic elsewhere before the listener closes. A
+	// second signal skips the wait.
+	healthService.StartDraining()
+	srv.SetKeepAlivesEnabled(false)
+	if cfg.Server.DrainDelay > 0 {
+		slog.Info("Draining connections", "delay", cfg.Server.DrainDelay)
+		select {
+		case <-time.After(cfg.Server.DrainDelay):
+		case <-quit:
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server gagal mati dengan graceful: %v", err)
+		fatal("Server did not shut down gracefully", err)
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			slog.Error("Metrics server did not shut down gracefully", "error", err)
+		}
+	}
+
+	slog.Info("Server stopped")
+}
+
+// loadConfig loads the configuration and switches logging to its mode. It
+// prints every configuration problem at once and reports false on failure.
+func loadConfig() (*config.Config, bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, false
 	}
 
-	log.Println("Server dihentikan.")
+	logging.Setup(cfg.Server.Mode)
+	slog.Info("Configuration loaded", "file", os.Getenv(config.FileEnv), "mode", cfg.Server.Mode)
+	return cfg, true
+}
+
+// fatal logs err and exits; like log.Fatal it skips deferred calls.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,105 @@
+// Package metrics holds the application's Prometheus collectors. They live
+// on a dedicated registry, exposed by Handler, rather than the global one so
+// only metrics registered here are published.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Login outcomes recorded by LoginAttempts.
+const (
+	LoginSucceeded   = "succeeded"
+	LoginFailed      = "failed"
+	LoginBlocked     = "blocked"
+	LoginDeactivated = "deactivated"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being served.",
+	})
+
+	loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Login attempts by outcome: succeeded, failed, blocked or deactivated.",
+	}, []string{"outcome"})
+
+	quizSubmissions = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "quiz_submissions_total",
+		Help: "Quiz attempts graded and recorded.",
+	})
+
+	profileImageUploads = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "profile_image_uploads_total",
+		Help: "Profile images stored successfully.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		httpRequestsInFlight,
+		loginAttempts,
+		quizSubmissions,
+		profileImageUploads,
+	)
+	// Expose every outcome from the start so rates work before the first
+	// occurrence.
+	for _, outcome := range []string{LoginSucceeded, LoginFailed, LoginBlocked, LoginDeactivated} {
+		loginAttempts.WithLabelValues(outcome)
+	}
+}
+
+// RegisterDB publishes the connection pool statistics (sql.DBStats) of db
+// under the given name.
+func RegisterDB(name string, db *sql.DB) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// RequestStarted and ObserveRequest track one HTTP request. route should be
+// the route pattern, not the raw path, to keep label cardinality bounded.
+func RequestStarted() {
+	httpRequestsInFlight.Inc()
+}
+
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	httpRequestsInFlight.Dec()
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+}
+
+// LoginAttempt counts a login with one of the Login* outcomes.
+func LoginAttempt(outcome string) {
+	loginAttempts.WithLabelValues(outcome).Inc()
+}
+
+func QuizSubmitted() {
+	quizSubmissions.Inc()
+}
+
+func ProfileImageUploaded() {
+	profileImageUploads.Inc()
+}
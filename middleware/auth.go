@@ -1,35 +1,42 @@
 package middleware
 
 import (
+	"be-education/apperrors"
 	"be-education/config"
+	"be-education/logging"
+	"be-education/models"
+	"be-education/service"
 	"be-education/utils"
-	"net/http"
+	"fmt"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthMiddleware struct {
-	jwtUtil *utils.JWTUtil
+	jwtUtil           *utils.JWTUtil
+	tokenService      service.TokenService
+	classService      service.ClassService
+	permissionService service.PermissionService
 }
 
-func NewAuthMiddleware(cfg *config.Config) *AuthMiddleware {
-	jwtUtil := utils.NewJWTUtil(cfg.SecretKey)
-	return &AuthMiddleware{jwtUtil: jwtUtil}
+func NewAuthMiddleware(cfg *config.Config, tokenService service.TokenService, classService service.ClassService, permissionService service.PermissionService) *AuthMiddleware {
+	jwtUtil := utils.NewJWTUtil(cfg.SecretKey, cfg.Token.AccessTTL)
+	return &AuthMiddleware{jwtUtil: jwtUtil, tokenService: tokenService, classService: classService, permissionService: permissionService}
 }
 
 func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.Request.Header.Get("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+			c.Error(apperrors.Unauthorized("Authorization token is required"))
 			c.Abort()
 			return
 		}
 
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || strings.ToLower(tokenParts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format (Expected 'Bearer <token>')"})
+			c.Error(apperrors.Unauthorized("Invalid authorization header format (Expected 'Bearer <token>')"))
 			c.Abort()
 			return
 		}
@@ -38,12 +45,54 @@ func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 
 		claims, err := m.jwtUtil.ParseJWTToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token", "details": err.Error()})
+			c.Error(apperrors.Unauthorized("Invalid or expired token").Wrap(err))
 			c.Abort()
 			return
 		}
 
+		revoked, err := m.tokenService.IsAccessTokenRevoked(c.Request.Context(), claims.ID)
+		if err != nil {
+			c.Error(fmt.Errorf("failed to check token revocation for user %d: %w", claims.UserID, err))
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.Error(apperrors.Unauthorized("Token has been revoked"))
+			c.Abort()
+			return
+		}
+
+		active, err := m.tokenService.IsUserActive(c.Request.Context(), claims.UserID)
+		if err != nil {
+			c.Error(fmt.Errorf("failed to check account status for user %d: %w", claims.UserID, err))
+			c.Abort()
+			return
+		}
+		if !active {
+			c.Error(apperrors.Unauthorized("Account is deactivated"))
+			c.Abort()
+			return
+		}
+
+		// Later log records of the request, including the access log,
+		// carry the caller's user ID.
+		c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), claims.UserID))
+
+		// Services narrow their results to the caller's scope; a guru's
+		// classes are loaded on every request so reassignments apply
+		// immediately.
+		scope := &models.Scope{UserID: claims.UserID, Role: claims.Role}
+		if scope.ClassRestricted() {
+			scope.ClassIDs, err = m.classService.GetTeacherClassIDs(c.Request.Context(), claims.UserID)
+			if err != nil {
+				c.Error(fmt.Errorf("failed to load class scope for user %d: %w", claims.UserID, err))
+				c.Abort()
+				return
+			}
+		}
+
 		utils.SetUserClaimsToContext(c, claims)
+		utils.SetScopeToContext(c, scope)
 
 		c.Next()
 	}
@@ -53,7 +102,7 @@ func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		currentUserClaims, ok := utils.GetCurrentUserClaims(c)
 		if !ok || currentUserClaims == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User context not found. Authentication required."})
+			c.Error(apperrors.Unauthorized("User context not found. Authentication required."))
 			c.Abort()
 			return
 		}
@@ -67,7 +116,35 @@ func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 		}
 
 		if !isAuthorized {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to access this resource. Insufficient role."})
+			c.Error(apperrors.Forbidden("You are not authorized to access this resource. Insufficient role."))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission lets the request through when the caller's role has been
+// granted the permission. It must run after Auth.
+func (m *AuthMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUserClaims, ok := utils.GetCurrentUserClaims(c)
+		if !ok || currentUserClaims == nil {
+			c.Error(apperrors.Unauthorized("User context not found. Authentication required."))
+			c.Abort()
+			return
+		}
+
+		allowed, err := m.permissionService.HasPermission(c.Request.Context(), currentUserClaims.Role, permission)
+		if err != nil {
+			c.Error(fmt.Errorf("failed to check permission %s for user %d: %w", permission, currentUserClaims.UserID, err))
+			c.Abort()
+			return
+		}
+
+		if !allowed {
+			c.Error(apperrors.Forbidden("You are not authorized to access this resource. Missing permission %s.", permission))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"be-education/config"
+	"log/slog"
+	"slices"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// exposedHeaders are the response headers browser clients always need to
+// read: the request ID for support tickets, the pagination headers set by
+// the list handlers, Retry-After on throttled logins and the file name of
+// exports.
+var exposedHeaders = []string{
+	"Content-Length",
+	"Content-Disposition",
+	"Retry-After",
+	RequestIDHeader,
+	"X-Total-Count",
+	"X-Page",
+	"X-Per-Page",
+	"X-Total-Pages",
+}
+
+// CORS applies the configured cross-origin policy and logs it, so the
+// effective policy of a deployment can be checked from its startup logs.
+// The policy is expected to have passed config validation.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	expose := slices.Clone(exposedHeaders)
+	for _, header := range cfg.ExposeHeaders {
+		if !slices.Contains(expose, header) {
+			expose = append(expose, header)
+		}
+	}
+
+	policy := cors.Config{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowWildcard:    true,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    expose,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+
+	slog.Info("CORS policy",
+		"allow_origins", policy.AllowOrigins,
+		"allow_methods", policy.AllowMethods,
+		"allow_headers", policy.AllowHeaders,
+		"expose_headers", policy.ExposeHeaders,
+		"allow_credentials", policy.AllowCredentials,
+		"max_age", policy.MaxAge,
+	)
+
+	return cors.New(policy)
+}
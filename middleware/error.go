@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"be-education/apperrors"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler renders the last error recorded with c.Error as the standard
+// JSON envelope:
+//
+//	{"error": "<message>", "code": "<kind>", "fields": {...}}
+//
+// Errors carrying a RetryAfter also set the Retry-After header and a
+// "retry_after" field in seconds. Unknown errors become a 500 with a generic
+// message and are logged.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		status, code := classify(err)
+
+		body := gin.H{"code": code}
+		var appErr *apperrors.Error
+		if status != http.StatusInternalServerError && errors.As(err, &appErr) {
+			body["error"] = appErr.Message
+			if len(appErr.Fields) > 0 {
+				body["fields"] = appErr.Fields
+			}
+			if appErr.RetryAfter > 0 {
+				seconds := int64(math.Ceil(appErr.RetryAfter.Seconds()))
+				c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+				body["retry_after"] = seconds
+			}
+		} else {
+			slog.ErrorContext(c.Request.Context(), "Request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+			body["error"] = "Internal server error"
+		}
+
+		c.JSON(status, body)
+	}
+}
+
+func classify(err error) (int, string) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		return http.StatusBadRequest, "validation_error"
+	case errors.Is(err, apperrors.ErrUnauthorized):
+		return http.StatusUnauthorized, "unauthorized"
+	case errors.Is(err, apperrors.ErrForbidden):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, apperrors.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, apperrors.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case errors.Is(err, apperrors.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate_limited"
+	default:
+		return http.StatusInternalServerError, "internal_error"
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"be-education/metrics"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the latency of every request in the per-route histogram.
+// Requests that match no route share the "unmatched" label. It must run
+// before Recovery to see the status of recovered panics; the deferred
+// observation keeps the in-flight gauge right even if it does not.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.RequestStarted()
+		defer func() {
+			route := c.FullPath()
+			if route == "" {
+				route = "unmatched"
+			}
+			metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+		}()
+
+		c.Next()
+	}
+}
+
+// MetricsHandler serves the metrics endpoint. With a non-empty token the
+// scraper must send it as a bearer token.
+func MetricsHandler(token string) http.Handler {
+	handler := metrics.Handler()
+	if token == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
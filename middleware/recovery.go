@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panic in a handler into a 500 response and logs it with
+// the request's context, replacing gin's plain-text recovery logger.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// http.ErrAbortHandler deliberately aborts the response.
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(recovered)
+			}
+
+			slog.ErrorContext(c.Request.Context(), "panic recovered",
+				"panic", recovered,
+				"stack", string(debug.Stack()),
+			)
+			if !c.Writer.Written() {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error", "code": "internal_error"})
+				return
+			}
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"be-education/apperrors"
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireSetupToken guards first-run bootstrap endpoints. The caller must
+// send the configured token in the X-Setup-Token header.
+func RequireSetupToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-Setup-Token")
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Error(apperrors.Unauthorized("Invalid or missing setup token"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"be-education/db"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// runMigrate implements the "migrate" subcommand and returns the process exit
+// code.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	cfg, ok := loadConfig()
+	if !ok {
+		return 1
+	}
+
+	dbConn, err := db.Connect(cfg)
+	if err != nil {
+		slog.Error("Failed to connect to the database", "error", err)
+		return 1
+	}
+	defer db.Close(dbConn)
+
+	migrator, err := db.NewMigrator(dbConn)
+	if err != nil {
+		slog.Error("Failed to load migrations", "error", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			slog.Error("Migration failed", "error", err)
+			return 1
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if args[1] == "all" {
+				steps = math.MaxInt
+			} else if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintf(os.Stderr, "Invalid number of steps %q\n", args[1])
+				return 2
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			slog.Error("Migration rollback failed", "error", err)
+			return 1
+		}
+		fmt.Printf("Reverted %d migration(s)\n", reverted)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			slog.Error("Failed to read migration status", "error", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", ""
+			if s.Applied {
+				state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown migrate command %q\n\n%s\n", args[0], usage)
+		return 2
+	}
+
+	return 0
+}
@@ -2,9 +2,20 @@ package models
 
 import "time"
 
+// Score policies decide which quiz attempt counts as the chapter score.
+const (
+	ScorePolicyBest    = "best"
+	ScorePolicyLatest  = "latest"
+	ScorePolicyAverage = "average"
+	ScorePolicyFirst   = "first"
+)
+
 type Chapter struct {
-	ID        int64     `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID          int64     `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Position    int       `json:"position" db:"position"`
+	ScorePolicy string    `json:"score_policy" db:"score_policy"`
+	MaxAttempts int       `json:"max_attempts" db:"max_attempts"` // 0 means unlimited
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
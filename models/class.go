@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Class is a rombel: a named group of students for one academic year.
+type Class struct {
+	ID              int64     `json:"id" db:"id"`
+	Name            string    `json:"name" db:"name"`
+	AcademicYear    *string   `json:"academic_year" db:"academic_year"` // e.g. "2025/2026"
+	HomeroomTeacher *string   `json:"homeroom_teacher" db:"homeroom_teacher"`
+	StudentCount    int       `json:"student_count" db:"student_count"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
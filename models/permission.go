@@ -0,0 +1,33 @@
+package models
+
+// Permission names checked by the routes. Every name must exist in the
+// permissions table; see the permissions migration.
+const (
+	PermUsersRead          = "users.read"
+	PermUsersCreate        = "users.create"
+	PermUsersUpdate        = "users.update"
+	PermUsersDelete        = "users.delete"
+	PermUsersDeactivate    = "users.deactivate"
+	PermUsersUnlock        = "users.unlock"
+	PermAdminsManage       = "admins.manage"
+	PermClassesManage      = "classes.manage"
+	PermChaptersManage     = "chapters.manage"
+	PermQuizzesReadAnswers = "quizzes.read_answers"
+	PermScoresRead         = "scores.read"
+	PermScoresReadAll      = "scores.read_all"
+	PermPermissionsManage  = "permissions.manage"
+)
+
+// Roles lists the roles a user can have.
+var Roles = []string{"admin", "guru", "mahasiswa"}
+
+type Permission struct {
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+}
+
+// RolePermission grants a permission to every user with the role.
+type RolePermission struct {
+	Role       string `json:"role" db:"role"`
+	Permission string `json:"permission" db:"permission"`
+}
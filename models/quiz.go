@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	QuestionTypeSingleChoice   = "single_choice"
+	QuestionTypeMultipleChoice = "multiple_choice"
+	QuestionTypeShortAnswer    = "short_answer"
+)
+
+type Quiz struct {
+	ID          int64     `json:"id" db:"id"`
+	ChapterID   int64     `json:"chapter_id" db:"chapter_id"`
+	Title       string    `json:"title" db:"title"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// QuizQuestion is a single question of a quiz. For short-answer questions the
+// options hold the accepted answers and are never shown to students.
+type QuizQuestion struct {
+	ID       int64        `json:"id" db:"id"`
+	QuizID   int64        `json:"quiz_id" db:"quiz_id"`
+	Type     string       `json:"type" db:"type"`
+	Prompt   string       `json:"prompt" db:"prompt"`
+	Points   float64      `json:"points" db:"points"`
+	Position int          `json:"position" db:"position"`
+	Options  []QuizOption `json:"options" db:"-"`
+}
+
+type QuizOption struct {
+	ID         int64  `json:"id" db:"id"`
+	QuestionID int64  `json:"question_id" db:"question_id"`
+	Text       string `json:"text" db:"text"`
+	IsCorrect  bool   `json:"is_correct" db:"is_correct"`
+	Position   int    `json:"position" db:"position"`
+}
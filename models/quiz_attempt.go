@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type QuizAttempt struct {
+	ID            int64     `json:"id" db:"id"`
+	UserID        int64     `json:"user_id" db:"user_id"`
+	ChapterID     int64     `json:"chapter_id" db:"chapter_id"`
+	QuizID        *int64    `json:"quiz_id,omitempty" db:"quiz_id"`
+	AttemptNumber int       `json:"attempt_number" db:"attempt_number"`
+	Score         float64   `json:"score" db:"score"`
+	EarnedPoints  float64   `json:"earned_points" db:"earned_points"`
+	TotalPoints   float64   `json:"total_points" db:"total_points"`
+	SubmittedAt   time.Time `json:"submitted_at" db:"submitted_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	ID              int64      `json:"id" db:"id"`
+	UserID          int64      `json:"user_id" db:"user_id"`
+	FamilyID        string     `json:"family_id" db:"family_id"`
+	TokenHash       string     `json:"-" db:"token_hash"`
+	AccessJTI       string     `json:"-" db:"access_jti"`
+	AccessExpiresAt time.Time  `json:"-" db:"access_expires_at"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedByID    *int64     `json:"replaced_by_id,omitempty" db:"replaced_by_id"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+// Scope narrows what a caller reaches once a route has let them in. Routes
+// are gated by RequirePermission; scope only narrows the guru role, which
+// reaches the students of the classes in ClassIDs and nothing else. Every
+// other role sees everything its permissions allow, so actions that must stay
+// with admins check a permission such as admins.manage rather than relying
+// on scope.
+type Scope struct {
+	UserID   int64
+	Role     string
+	ClassIDs []int64
+}
+
+// ClassRestricted reports whether the caller is limited to ClassIDs.
+func (s *Scope) ClassRestricted() bool {
+	return s.Role == "guru"
+}
+
+// ClassFilter returns the classes listings must be limited to, or nil when
+// the caller is not restricted. A guru without classes gets an empty,
+// non-nil slice, which matches nothing.
+func (s *Scope) ClassFilter() []int64 {
+	if !s.ClassRestricted() {
+		return nil
+	}
+	if s.ClassIDs == nil {
+		return []int64{}
+	}
+	return s.ClassIDs
+}
+
+// CanAccessClass reports whether students of the class are within reach. A
+// nil class ID stands for students without a class, which no guru covers.
+func (s *Scope) CanAccessClass(classID *int64) bool {
+	if !s.ClassRestricted() {
+		return true
+	}
+	if classID == nil {
+		return false
+	}
+	for _, id := range s.ClassIDs {
+		if id == *classID {
+			return true
+		}
+	}
+	return false
+}
@@ -7,10 +7,19 @@ type User struct {
 	Name       string     `json:"name" db:"name"`
 	Email      string     `json:"email" db:"email"`
 	Password   string     `json:"password" db:"password"`
-	Class      *string    `json:"class,omitempty" db:"class"`
+	ClassID    *int64     `json:"class_id,omitempty" db:"class_id"`
+	ClassName  *string    `json:"class_name,omitempty" db:"class_name"` // read-only, joined from classes
 	Birthday   *time.Time `json:"birthday,omitempty" db:"birthday"`
 	Role       string     `json:"role" db:"role"`
 	ProfileURL *string    `json:"profile_url,omitempty" db:"profile_url"`
+	IsActive   bool       `json:"is_active" db:"is_active"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
+
+// Active reports whether the user may sign in: neither deactivated nor
+// soft-deleted.
+func (u *User) Active() bool {
+	return u.IsActive && u.DeletedAt == nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"be-education/apperrors"
+	"be-education/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ChapterRepository interface {
+	CreateChapter(ctx context.Context, chapter *models.Chapter) error
+	GetChapterByID(ctx context.Context, id int64) (*models.Chapter, error)
+	GetAllChapters(ctx context.Context) ([]*models.Chapter, error)
+	UpdateChapter(ctx context.Context, chapter *models.Chapter) error
+	DeleteChapter(ctx context.Context, id int64) error
+	ChapterExists(ctx context.Context, id int64) (bool, error)
+	ChapterNameExists(ctx context.Context, name string, excludeID int64) (bool, error)
+	HasUserChapters(ctx context.Context, chapterID int64) (bool, error)
+	ReorderChapters(ctx context.Context, chapterIDs []int64) error
+}
+
+type chapterRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewChapterRepository(db *sqlx.DB) ChapterRepository {
+	return &chapterRepositoryImpl{db: db}
+}
+
+func (r *chapterRepositoryImpl) CreateChapter(ctx context.Context, chapter *models.Chapter) error {
+	query := `
+		INSERT INTO chapters (name, position, score_policy, max_attempts, created_at, updated_at)
+		VALUES (:name, (SELECT COALESCE(MAX(position), 0) + 1 FROM chapters), :score_policy, :max_attempts, :created_at, :updated_at)
+		RETURNING id, position, created_at, updated_at`
+
+	chapter.CreatedAt = time.Now()
+	chapter.UpdatedAt = time.Now()
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query for chapter creation: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.GetContext(ctx, chapter, chapter)
+	if err != nil {
+		return dbError(err, "failed to create chapter")
+	}
+	return nil
+}
+
+func (r *chapterRepositoryImpl) GetChapterByID(ctx context.Context, id int64) (*models.Chapter, error) {
+	query := `
+		SELECT id, name, position, score_policy, max_attempts, created_at, updated_at
+		FROM chapters
+		WHERE id = $1`
+
+	chapter := &models.Chapter{}
+	err := r.db.GetContext(ctx, chapter, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("chapter with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get chapter by ID: %w", err)
+	}
+	return chapter, nil
+}
+
+func (r *chapterRepositoryImpl) GetAllChapters(ctx context.Context) ([]*models.Chapter, error) {
+	query := `
+		SELECT id, name, position, score_policy, max_attempts, created_at, updated_at
+		FROM chapters
+		ORDER BY position, id`
+
+	chapters := []*models.Chapter{}
+	err := r.db.SelectContext(ctx, &chapters, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all chapters: %w", err)
+	}
+	return chapters, nil
+}
+
+func (r *chapterRepositoryImpl) UpdateChapter(ctx context.Context, chapter *models.Chapter) error {
+	query := `
+		UPDATE chapters
+		SET name = :name, score_policy = :score_policy, max_attempts = :max_attempts, updated_at = :updated_at
+		WHERE id = :id`
+
+	chapter.UpdatedAt = time.Now()
+
+	res, err := r.db.NamedExecContext(ctx, query, chapter)
+	if err != nil {
+		return dbError(err, "failed to update chapter")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperrors.NotFound("chapter with ID %d not found", chapter.ID)
+	}
+	return nil
+}
+
+// DeleteChapter removes the chapter and closes the gap it leaves in the
+// ordering so positions stay contiguous.
+func (r *chapterRepositoryImpl) DeleteChapter(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin chapter deletion: %w", err)
+	}
+	defer tx.Rollback()
+
+	var position int
+	err = tx.GetContext(ctx, &position, `DELETE FROM chapters WHERE id = $1 RETURNING position`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.NotFound("chapter with ID %d not found", id)
+		}
+		return fmt.Errorf("failed to delete chapter: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE chapters SET position = position - 1 WHERE position > $1`, position)
+	if err != nil {
+		return fmt.Errorf("failed to compact chapter positions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chapter deletion: %w", err)
+	}
+	return nil
+}
+
+func (r *chapterRepositoryImpl) ChapterExists(ctx context.Context, id int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM chapters WHERE id = $1)`
+
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to check chapter existence: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *chapterRepositoryImpl) ChapterNameExists(ctx context.Context, name string, excludeID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM chapters WHERE LOWER(TRIM(name)) = LOWER(TRIM($1)) AND id <> $2)`
+
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, query, name, excludeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check chapter name: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *chapterRepositoryImpl) HasUserChapters(ctx context.Context, chapterID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM user_chapters WHERE chapter_id = $1)`
+
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, query, chapterID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check chapter usage: %w", err)
+	}
+	return exists, nil
+}
+
+// ReorderChapters assigns positions 1..n following the order of chapterIDs.
+// The caller is expected to pass every chapter exactly once.
+func (r *chapterRepositoryImpl) ReorderChapters(ctx context.Context, chapterIDs []int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin chapter reorder: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i, id := range chapterIDs {
+		res, err := tx.ExecContext(ctx, `UPDATE chapters SET position = $1, updated_at = $2 WHERE id = $3`, i+1, now, id)
+		if err != nil {
+			return fmt.Errorf("failed to update position of chapter %d: %w", id, err)
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return apperrors.NotFound("chapter with ID %d not found", id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chapter reorder: %w", err)
+	}
+	return nil
+}
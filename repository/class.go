@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type ClassRepository interface {
+	CreateClass(ctx context.Context, class *models.Class) error
+	GetClassByID(ctx context.Context, id int64) (*models.Class, error)
+	GetClassByName(ctx context.Context, name string) (*models.Class, error)
+	GetAllClasses(ctx context.Context, academicYear string, classIDs []int64) ([]*models.Class, error)
+	UpdateClass(ctx context.Context, class *models.Class) error
+	DeleteClass(ctx context.Context, id int64) error
+	HasUsers(ctx context.Context, classID int64) (bool, error)
+	GetClassTeachers(ctx context.Context, classID int64) ([]dto.ClassTeacher, error)
+	SetClassTeachers(ctx context.Context, classID int64, teacherIDs []int64) error
+	GetTeacherClassIDs(ctx context.Context, userID int64) ([]int64, error)
+}
+
+// classNameKeySQL normalizes a class name the same way as the generated
+// classes.name_key column.
+const classNameKeySQL = `LOWER(TRIM(REGEXP_REPLACE($1, '[^[:alnum:]]+', ' ', 'g')))`
+
+const classColumnsSQL = `
+	c.id, c.name, c.academic_year, c.homeroom_teacher, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM users u
+	 WHERE u.class_id = c.id AND u.role = 'mahasiswa' AND u.is_active AND u.deleted_at IS NULL) AS student_count`
+
+type classRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewClassRepository(db *sqlx.DB) ClassRepository {
+	return &classRepositoryImpl{db: db}
+}
+
+func (r *classRepositoryImpl) CreateClass(ctx context.Context, class *models.Class) error {
+	query := `
+		INSERT INTO classes (name, academic_year, homeroom_teacher, created_at, updated_at)
+		VALUES (:name, :academic_year, :homeroom_teacher, :created_at, :updated_at)
+		RETURNING id, created_at, updated_at`
+
+	class.CreatedAt = time.Now()
+	class.UpdatedAt = time.Now()
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query for class creation: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.GetContext(ctx, class, class)
+	if err != nil {
+		return dbError(err, "failed to create class")
+	}
+	return nil
+}
+
+func (r *classRepositoryImpl) GetClassByID(ctx context.Context, id int64) (*models.Class, error) {
+	query := `SELECT ` + classColumnsSQL + ` FROM classes c WHERE c.id = $1`
+
+	class := &models.Class{}
+	err := r.db.GetContext(ctx, class, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("class with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get class by ID: %w", err)
+	}
+	return class, nil
+}
+
+// GetClassByName matches the name loosely, ignoring case and punctuation.
+// When several academic years use the name, the most recent one wins.
+func (r *classRepositoryImpl) GetClassByName(ctx context.Context, name string) (*models.Class, error) {
+	query := `
+		SELECT ` + classColumnsSQL + `
+		FROM classes c
+		WHERE c.name_key = ` + classNameKeySQL + `
+		ORDER BY c.academic_year DESC NULLS LAST, c.id DESC
+		LIMIT 1`
+
+	class := &models.Class{}
+	err := r.db.GetContext(ctx, class, query, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("class %q not found", name)
+		}
+		return nil, fmt.Errorf("failed to get class by name: %w", err)
+	}
+	return class, nil
+}
+
+// GetAllClasses lists the classes of one academic year, or all classes when
+// academicYear is empty. A non-nil classIDs limits the result to those
+// classes.
+func (r *classRepositoryImpl) GetAllClasses(ctx context.Context, academicYear string, classIDs []int64) ([]*models.Class, error) {
+	query := `
+		SELECT ` + classColumnsSQL + `
+		FROM classes c
+		WHERE ($1 = '' OR c.academic_year = $1)
+		  AND ($2::bigint[] IS NULL OR c.id = ANY($2))
+		ORDER BY c.academic_year DESC NULLS LAST, c.name, c.id`
+
+	classes := []*models.Class{}
+	err := r.db.SelectContext(ctx, &classes, query, academicYear, pq.Array(classIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all classes: %w", err)
+	}
+	return classes, nil
+}
+
+func (r *classRepositoryImpl) UpdateClass(ctx context.Context, class *models.Class) error {
+	query := `
+		UPDATE classes
+		SET name = :name, academic_year = :academic_year, homeroom_teacher = :homeroom_teacher, updated_at = :updated_at
+		WHERE id = :id`
+
+	class.UpdatedAt = time.Now()
+
+	res, err := r.db.NamedExecContext(ctx, query, class)
+	if err != nil {
+		return dbError(err, "failed to update class")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperrors.NotFound("class with ID %d not found", class.ID)
+	}
+	return nil
+}
+
+func (r *classRepositoryImpl) DeleteClass(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM classes WHERE id = $1`, id)
+	if err != nil {
+		return dbError(err, "failed to delete class")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperrors.NotFound("class with ID %d not found", id)
+	}
+	return nil
+}
+
+// HasUsers reports whether any user row, including soft-deleted ones, still
+// references the class.
+func (r *classRepositoryImpl) HasUsers(ctx context.Context, classID int64) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM users WHERE class_id = $1)`, classID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check class usage: %w", err)
+	}
+	return exists, nil
+}
+
+// GetClassTeachers lists the active guru accounts assigned to the class.
+func (r *classRepositoryImpl) GetClassTeachers(ctx context.Context, classID int64) ([]dto.ClassTeacher, error) {
+	query := `
+		SELECT u.id, u.name, u.email
+		FROM class_teachers ct
+		JOIN users u ON u.id = ct.user_id
+		WHERE ct.class_id = $1 AND u.role = 'guru' AND ` + activeUserSQL + `
+		ORDER BY u.name, u.id`
+
+	teachers := []dto.ClassTeacher{}
+	err := r.db.SelectContext(ctx, &teachers, query, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get class teachers: %w", err)
+	}
+	return teachers, nil
+}
+
+// SetClassTeachers replaces the teacher assignments of the class.
+func (r *classRepositoryImpl) SetClassTeachers(ctx context.Context, classID int64, teacherIDs []int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin class teacher update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM class_teachers WHERE class_id = $1`, classID); err != nil {
+		return fmt.Errorf("failed to clear class teachers: %w", err)
+	}
+
+	query := `
+		INSERT INTO class_teachers (class_id, user_id)
+		SELECT $1, UNNEST($2::bigint[])
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, classID, pq.Array(teacherIDs)); err != nil {
+		return dbError(err, "failed to assign class teachers")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit class teacher update: %w", err)
+	}
+	return nil
+}
+
+// GetTeacherClassIDs lists the classes a guru is assigned to. Assignments
+// left over from before a role change are ignored.
+func (r *classRepositoryImpl) GetTeacherClassIDs(ctx context.Context, userID int64) ([]int64, error) {
+	query := `
+		SELECT ct.class_id
+		FROM class_teachers ct
+		JOIN users u ON u.id = ct.user_id
+		WHERE ct.user_id = $1 AND u.role = 'guru'
+		ORDER BY ct.class_id`
+
+	classIDs := []int64{}
+	err := r.db.SelectContext(ctx, &classIDs, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get teacher classes: %w", err)
+	}
+	return classIDs, nil
+}
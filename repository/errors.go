@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"be-education/apperrors"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// conflictMessages gives client-facing messages for unique constraints that
+// users can realistically hit.
+var conflictMessages = map[string]string{
+	"users_email_key":                      "email is already registered",
+	"user_chapters_user_id_chapter_id_key": "chapter progress already exists for this user",
+	"quizzes_chapter_id_key":               "chapter already has a quiz",
+	"classes_name_key_academic_year_key":   "a class with this name already exists for the academic year",
+}
+
+// dbError maps Postgres constraint violations onto apperrors kinds and wraps
+// everything else with the given context.
+func dbError(err error, format string, args ...any) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			message, ok := conflictMessages[pqErr.Constraint]
+			if !ok {
+				message = "resource already exists"
+			}
+			return apperrors.Conflict("%s", message).Wrap(err)
+		case "23503": // foreign_key_violation
+			return apperrors.Validation("referenced resource does not exist").Wrap(err)
+		case "23514", "22001": // check_violation, string_data_right_truncation
+			return apperrors.Validation("invalid value").Wrap(err)
+		}
+	}
+	return fmt.Errorf(format+": %w", append(args, err)...)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"be-education/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PasswordResetRepository interface {
+	CreateResetToken(ctx context.Context, token *models.PasswordResetToken) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int64, error)
+}
+
+type passwordResetRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewPasswordResetRepository(db *sqlx.DB) PasswordResetRepository {
+	return &passwordResetRepositoryImpl{db: db}
+}
+
+func (r *passwordResetRepositoryImpl) CreateResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES (:user_id, :token_hash, :expires_at, :created_at)
+		RETURNING id, created_at`
+
+	token.CreatedAt = time.Now()
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query for password reset token creation: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.GetContext(ctx, token, token)
+	if err != nil {
+		return dbError(err, "failed to create password reset token")
+	}
+	return nil
+}
+
+// ResetPassword consumes the reset token, stores the new password hash and
+// burns every other outstanding reset token of the same user, all in one
+// transaction. It returns 0 when the token is unknown, used or expired.
+func (r *passwordResetRepositoryImpl) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin password reset: %w", err)
+	}
+	defer tx.Rollback()
+
+	consumeQuery := `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`
+
+	var userID int64
+	err = tx.GetContext(ctx, &userID, consumeQuery, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to consume password reset token: %w", err)
+	}
+
+	updateQuery := `
+		UPDATE users
+		SET password = $1, updated_at = $2
+		WHERE id = $3`
+
+	res, err := tx.ExecContext(ctx, updateQuery, passwordHash, time.Now(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update password: %w", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected for password update: %w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+
+	invalidateQuery := `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL`
+
+	if _, err := tx.ExecContext(ctx, invalidateQuery, userID); err != nil {
+		return 0, fmt.Errorf("failed to invalidate remaining password reset tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit password reset: %w", err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"be-education/models"
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type PermissionRepository interface {
+	GetAllPermissions(ctx context.Context) ([]*models.Permission, error)
+	GetRolePermissions(ctx context.Context) ([]models.RolePermission, error)
+	SetRolePermissions(ctx context.Context, role string, permissions []string) error
+}
+
+type permissionRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewPermissionRepository(db *sqlx.DB) PermissionRepository {
+	return &permissionRepositoryImpl{db: db}
+}
+
+func (r *permissionRepositoryImpl) GetAllPermissions(ctx context.Context) ([]*models.Permission, error) {
+	permissions := []*models.Permission{}
+	err := r.db.SelectContext(ctx, &permissions, `SELECT name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// GetRolePermissions returns every role-permission grant.
+func (r *permissionRepositoryImpl) GetRolePermissions(ctx context.Context) ([]models.RolePermission, error) {
+	grants := []models.RolePermission{}
+	err := r.db.SelectContext(ctx, &grants, `SELECT role, permission FROM role_permissions ORDER BY role, permission`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+	return grants, nil
+}
+
+// SetRolePermissions replaces the permissions granted to the role.
+func (r *permissionRepositoryImpl) SetRolePermissions(ctx context.Context, role string, permissions []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin role permission update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role = $1`, role); err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+
+	query := `
+		INSERT INTO role_permissions (role, permission)
+		SELECT $1, UNNEST($2::varchar[])
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, role, pq.Array(permissions)); err != nil {
+		return dbError(err, "failed to grant role permissions")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit role permission update: %w", err)
+	}
+	return nil
+}
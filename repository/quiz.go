@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"be-education/apperrors"
+	"be-education/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type QuizRepository interface {
+	GetQuizByChapterID(ctx context.Context, chapterID int64) (*models.Quiz, error)
+	GetQuestionsByQuizID(ctx context.Context, quizID int64) ([]*models.QuizQuestion, error)
+	SaveQuiz(ctx context.Context, quiz *models.Quiz, questions []*models.QuizQuestion) error
+	DeleteQuizByChapterID(ctx context.Context, chapterID int64) error
+}
+
+type quizRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewQuizRepository(db *sqlx.DB) QuizRepository {
+	return &quizRepositoryImpl{db: db}
+}
+
+func (r *quizRepositoryImpl) GetQuizByChapterID(ctx context.Context, chapterID int64) (*models.Quiz, error) {
+	query := `
+		SELECT id, chapter_id, title, description, created_at, updated_at
+		FROM quizzes
+		WHERE chapter_id = $1`
+
+	quiz := &models.Quiz{}
+	err := r.db.GetContext(ctx, quiz, query, chapterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("quiz for chapter %d not found", chapterID)
+		}
+		return nil, fmt.Errorf("failed to get quiz by chapter ID: %w", err)
+	}
+	return quiz, nil
+}
+
+// GetQuestionsByQuizID returns the questions in display order with their
+// options (or accepted answers) attached.
+func (r *quizRepositoryImpl) GetQuestionsByQuizID(ctx context.Context, quizID int64) ([]*models.QuizQuestion, error) {
+	questionQuery := `
+		SELECT id, quiz_id, type, prompt, points, position
+		FROM quiz_questions
+		WHERE quiz_id = $1
+		ORDER BY position, id`
+
+	questions := []*models.QuizQuestion{}
+	if err := r.db.SelectContext(ctx, &questions, questionQuery, quizID); err != nil {
+		return nil, fmt.Errorf("failed to get quiz questions: %w", err)
+	}
+
+	optionQuery := `
+		SELECT o.id, o.question_id, o.text, o.is_correct, o.position
+		FROM quiz_options o
+		JOIN quiz_questions q ON q.id = o.question_id
+		WHERE q.quiz_id = $1
+		ORDER BY o.question_id, o.position, o.id`
+
+	var options []models.QuizOption
+	if err := r.db.SelectContext(ctx, &options, optionQuery, quizID); err != nil {
+		return nil, fmt.Errorf("failed to get quiz options: %w", err)
+	}
+
+	byQuestion := make(map[int64]*models.QuizQuestion, len(questions))
+	for _, q := range questions {
+		byQuestion[q.ID] = q
+	}
+	for _, o := range options {
+		if q, ok := byQuestion[o.QuestionID]; ok {
+			q.Options = append(q.Options, o)
+		}
+	}
+
+	return questions, nil
+}
+
+// SaveQuiz creates or replaces the quiz of quiz.ChapterID. Existing questions
+// and options are replaced wholesale in the same transaction.
+func (r *quizRepositoryImpl) SaveQuiz(ctx context.Context, quiz *models.Quiz, questions []*models.QuizQuestion) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin quiz save: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	quiz.UpdatedAt = now
+
+	upsertQuery := `
+		INSERT INTO quizzes (chapter_id, title, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (chapter_id) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at`
+
+	err = tx.QueryRowxContext(ctx, upsertQuery, quiz.ChapterID, quiz.Title, quiz.Description, now).
+		Scan(&quiz.ID, &quiz.CreatedAt)
+	if err != nil {
+		return dbError(err, "failed to save quiz")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quiz_questions WHERE quiz_id = $1`, quiz.ID); err != nil {
+		return fmt.Errorf("failed to clear quiz questions: %w", err)
+	}
+
+	questionQuery := `
+		INSERT INTO quiz_questions (quiz_id, type, prompt, points, position)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	optionQuery := `
+		INSERT INTO quiz_options (question_id, text, is_correct, position)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	for i, q := range questions {
+		q.QuizID = quiz.ID
+		q.Position = i + 1
+		if err := tx.GetContext(ctx, &q.ID, questionQuery, q.QuizID, q.Type, q.Prompt, q.Points, q.Position); err != nil {
+			return fmt.Errorf("failed to insert quiz question %d: %w", q.Position, err)
+		}
+
+		for j := range q.Options {
+			o := &q.Options[j]
+			o.QuestionID = q.ID
+			o.Position = j + 1
+			if err := tx.GetContext(ctx, &o.ID, optionQuery, o.QuestionID, o.Text, o.IsCorrect, o.Position); err != nil {
+				return fmt.Errorf("failed to insert option %d of question %d: %w", o.Position, q.Position, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit quiz save: %w", err)
+	}
+	return nil
+}
+
+func (r *quizRepositoryImpl) DeleteQuizByChapterID(ctx context.Context, chapterID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM quizzes WHERE chapter_id = $1`, chapterID)
+	if err != nil {
+		return fmt.Errorf("failed to delete quiz: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperrors.NotFound("quiz for chapter %d not found", chapterID)
+	}
+	return nil
+}
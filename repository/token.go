@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"be-education/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID int64, next *models.RefreshToken) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeSessionByAccessJTI(ctx context.Context, userID int64, jti string) error
+	RevokeAllUserTokens(ctx context.Context, userID int64) error
+	RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	PurgeExpiredTokens(ctx context.Context) (int64, error)
+}
+
+type tokenRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewTokenRepository(db *sqlx.DB) TokenRepository {
+	return &tokenRepositoryImpl{db: db}
+}
+
+// revokeFamilyAccessTokensQuery copies the still-valid access token IDs of the
+// selected refresh tokens into revoked_tokens so the middleware rejects them
+// before they expire on their own.
+const revokeFamilyAccessTokensQuery = `
+	INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+	SELECT access_jti, user_id, access_expires_at, NOW()
+	FROM refresh_tokens
+	WHERE %s AND access_expires_at > NOW()
+	ON CONFLICT (jti) DO NOTHING`
+
+func (r *tokenRepositoryImpl) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, access_jti, access_expires_at, expires_at, created_at)
+		VALUES (:user_id, :family_id, :token_hash, :access_jti, :access_expires_at, :expires_at, :created_at)
+		RETURNING id, created_at`
+
+	token.CreatedAt = time.Now()
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query for refresh token creation: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.GetContext(ctx, token, token)
+	if err != nil {
+		return dbError(err, "failed to create refresh token")
+	}
+	return nil
+}
+
+func (r *tokenRepositoryImpl) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, access_jti, access_expires_at, expires_at, revoked_at, replaced_by_id, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1`
+
+	token := &models.RefreshToken{}
+	err := r.db.GetContext(ctx, token, query, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// RotateRefreshToken marks the old token as replaced and stores its successor
+// in a single transaction. It reports false when the old token had already
+// been revoked, which means another request won the race to rotate it.
+func (r *tokenRepositoryImpl) RotateRefreshToken(ctx context.Context, oldID int64, next *models.RefreshToken) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin refresh token rotation: %w", err)
+	}
+	defer tx.Rollback()
+
+	next.CreatedAt = time.Now()
+
+	insertQuery := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, access_jti, access_expires_at, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	err = tx.GetContext(ctx, &next.ID, insertQuery,
+		next.UserID, next.FamilyID, next.TokenHash, next.AccessJTI, next.AccessExpiresAt, next.ExpiresAt, next.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert rotated refresh token: %w", err)
+	}
+
+	updateQuery := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW(), replaced_by_id = $1
+		WHERE id = $2 AND revoked_at IS NULL`
+
+	res, err := tx.ExecContext(ctx, updateQuery, next.ID, oldID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke rotated refresh token: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected for refresh token rotation: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit refresh token rotation: %w", err)
+	}
+	return true, nil
+}
+
+func (r *tokenRepositoryImpl) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	return r.revokeWhere(ctx, "family_id = $1", familyID)
+}
+
+func (r *tokenRepositoryImpl) RevokeSessionByAccessJTI(ctx context.Context, userID int64, jti string) error {
+	return r.revokeWhere(ctx, "family_id IN (SELECT family_id FROM refresh_tokens WHERE user_id = $1 AND access_jti = $2)", userID, jti)
+}
+
+func (r *tokenRepositoryImpl) RevokeAllUserTokens(ctx context.Context, userID int64) error {
+	return r.revokeWhere(ctx, "user_id = $1", userID)
+}
+
+func (r *tokenRepositoryImpl) revokeWhere(ctx context.Context, condition string, args ...interface{}) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin token revocation: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(revokeFamilyAccessTokensQuery, condition), args...); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	updateQuery := fmt.Sprintf(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE %s AND revoked_at IS NULL`, condition)
+
+	if _, err := tx.ExecContext(ctx, updateQuery, args...); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit token revocation: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepositoryImpl) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (jti) DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query, jti, userID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepositoryImpl) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	var revoked bool
+	err := r.db.GetContext(ctx, &revoked, query, jti)
+	if err != nil {
+		return false, fmt.Errorf("failed to check access token revocation: %w", err)
+	}
+	return revoked, nil
+}
+
+// PurgeExpiredTokens deletes refresh tokens and revocation entries past their
+// expiry. Neither is needed any more: an expired refresh token is refused
+// anyway, and an expired access token fails verification before the
+// revocation list is consulted.
+func (r *tokenRepositoryImpl) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	var purged int64
+	for _, table := range []string{"revoked_tokens", "refresh_tokens"} {
+		res, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE expires_at < NOW()`)
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge expired %s: %w", table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return purged, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		purged += n
+	}
+	return purged, nil
+}
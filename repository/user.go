@@ -1,14 +1,17 @@
 package repository
 
 import (
+	"be-education/apperrors"
 	"be-education/dto"
 	"be-education/models"
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type UserRepository interface {
@@ -17,12 +20,69 @@ type UserRepository interface {
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	UpdateUser(ctx context.Context, user *models.User) error
 	DeleteUser(ctx context.Context, id int64) error
+	SetUserActive(ctx context.Context, id int64, active bool) error
+	RestoreUser(ctx context.Context, id int64) error
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
 	GetAllUsers(ctx context.Context) ([]*models.User, error)
-	UpdateProfileURL(ctx context.Context, userID int64, profileURL string) error
-	GetStudentCountsByClass(ctx context.Context) (map[string]int, error)
-	GetAdmins(ctx context.Context) ([]*models.User, error)
+	UpdateProfileURL(ctx context.Context, userID int64, profileURL string) (*string, error)
+	GetProfileURLs(ctx context.Context) ([]string, error)
+	GetStudentCountsByClass(ctx context.Context, classIDs []int64) ([]dto.ClassStudentCount, error)
 	GetTotalAdmins(ctx context.Context) (int, error)
-	GetMahasiswaUsers(ctx context.Context) ([]*models.User, error)
+	ListUsers(ctx context.Context, filter UserListFilter) ([]*models.User, int, error)
+	CreateFirstAdmin(ctx context.Context, user *models.User) (bool, error)
+	GetExistingEmails(ctx context.Context, emails []string) (map[string]bool, error)
+	CreateUsers(ctx context.Context, users []*models.User) error
+}
+
+// Account states accepted by UserListFilter.Status.
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
+	UserStatusDeleted  = "deleted"
+	UserStatusAll      = "all"
+)
+
+// activeUserSQL matches users (aliased u) that can sign in and appear in
+// listings and summaries.
+const activeUserSQL = "u.is_active AND u.deleted_at IS NULL"
+
+// userColumnsSQL and userFromSQL select a models.User together with the
+// name of its class.
+const (
+	userColumnsSQL = `u.id, u.name, u.email, u.password, u.class_id, c.name AS class_name, u.birthday, u.role,
+		u.profile_url, u.is_active, u.deleted_at, u.created_at, u.updated_at`
+	userFromSQL = `users u LEFT JOIN classes c ON c.id = u.class_id`
+)
+
+// userStatusConditions maps UserListFilter.Status to its WHERE condition.
+var userStatusConditions = map[string]string{
+	UserStatusActive:   activeUserSQL,
+	UserStatusInactive: "NOT u.is_active AND u.deleted_at IS NULL",
+	UserStatusDeleted:  "u.deleted_at IS NOT NULL",
+	UserStatusAll:      "",
+}
+
+// UserListFilter narrows ListUsers. Empty fields are ignored, except Status
+// which defaults to active users, and ClassIDs, where only nil means any
+// class; Sort must be one of the keys of userSortColumns.
+type UserListFilter struct {
+	Status   string
+	Role     string
+	ClassID  int64
+	ClassIDs []int64
+	Search   string
+	Sort     string
+	Order    string
+	Limit    int
+	Offset   int
+}
+
+// userSortColumns whitelists the sortable columns so Sort can never inject
+// SQL.
+var userSortColumns = map[string]string{
+	"name":       "u.name",
+	"created_at": "u.created_at",
+	"class":      "c.name",
 }
 
 type userRepositoryImpl struct {
@@ -35,10 +95,11 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 
 func (r *userRepositoryImpl) CreateUser(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (name, email, password, class, birthday, role, profile_url, created_at, updated_at)
-		VALUES (:name, :email, :password, :class, :birthday, :role, :profile_url, :created_at, :updated_at)
+		INSERT INTO users (name, email, password, class_id, birthday, role, profile_url, is_active, created_at, updated_at)
+		VALUES (:name, :email, :password, :class_id, :birthday, :role, :profile_url, :is_active, :created_at, :updated_at)
 		RETURNING id, created_at, updated_at`
 
+	user.IsActive = true
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
@@ -50,23 +111,23 @@ func (r *userRepositoryImpl) CreateUser(ctx context.Context, user *models.User)
 
 	err = stmt.GetContext(ctx, user, user)
 	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+		return dbError(err, "failed to create user")
 	}
 	return nil
 }
 
 func (r *userRepositoryImpl) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
 	query := `
-		SELECT id, name, email, password, class, birthday, role, profile_url, created_at, updated_at
-		FROM users
-		WHERE id = $1`
+		SELECT ` + userColumnsSQL + `
+		FROM ` + userFromSQL + `
+		WHERE u.id = $1`
 
 	user := &models.User{}
 	err := r.db.GetContext(ctx, user, query, id)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with ID %d not found", id)
+			return nil, apperrors.NotFound("user with ID %d not found", id)
 		}
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
@@ -76,16 +137,16 @@ func (r *userRepositoryImpl) GetUserByID(ctx context.Context, id int64) (*models
 
 func (r *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, name, email, password, class, birthday, role, profile_url, created_at, updated_at
-		FROM users
-		WHERE email = $1`
+		SELECT ` + userColumnsSQL + `
+		FROM ` + userFromSQL + `
+		WHERE u.email = $1`
 
 	user := &models.User{}
 	err := r.db.GetContext(ctx, user, query, email)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with email %s not found", email)
+			return nil, apperrors.NotFound("user with email %s not found", email)
 		}
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -96,7 +157,7 @@ func (r *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (
 func (r *userRepositoryImpl) UpdateUser(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
-		SET name = :name, email = :email, password = :password, class = :class, birthday = :birthday,
+		SET name = :name, email = :email, password = :password, class_id = :class_id, birthday = :birthday,
 		    role = :role, profile_url = :profile_url, updated_at = :updated_at
 		WHERE id = :id`
 
@@ -108,7 +169,7 @@ func (r *userRepositoryImpl) UpdateUser(ctx context.Context, user *models.User)
 		user,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return dbError(err, "failed to update user")
 	}
 
 	rowsAffected, err := res.RowsAffected()
@@ -116,13 +177,18 @@ func (r *userRepositoryImpl) UpdateUser(ctx context.Context, user *models.User)
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("user with ID %d not found for update", user.ID)
+		return apperrors.NotFound("user with ID %d not found", user.ID)
 	}
 	return nil
 }
 
+// DeleteUser soft-deletes the user: the row and its grade history are kept
+// until PurgeDeletedUsers removes them, and RestoreUser can undo it.
 func (r *userRepositoryImpl) DeleteUser(ctx context.Context, id int64) error {
-	query := `DELETE FROM users WHERE id = $1`
+	query := `
+		UPDATE users
+		SET deleted_at = NOW(), is_active = FALSE, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	res, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
@@ -134,89 +200,145 @@ func (r *userRepositoryImpl) DeleteUser(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("user with ID %d not found for deletion", id)
+		return apperrors.NotFound("user with ID %d not found", id)
 	}
 	return nil
 }
 
-func (r *userRepositoryImpl) GetAllUsers(ctx context.Context) ([]*models.User, error) {
+// SetUserActive activates or deactivates a user that is not deleted.
+func (r *userRepositoryImpl) SetUserActive(ctx context.Context, id int64, active bool) error {
 	query := `
-		SELECT id, name, email, password, class, birthday, role, profile_url, created_at, updated_at
-		FROM users`
+		UPDATE users
+		SET is_active = $1, updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL`
 
-	users := []*models.User{}
-	err := r.db.SelectContext(ctx, &users, query)
+	res, err := r.db.ExecContext(ctx, query, active, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all users: %w", err)
+		return fmt.Errorf("failed to update user status: %w", err)
 	}
 
-	return users, nil
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperrors.NotFound("user with ID %d not found", id)
+	}
+	return nil
 }
 
-func (r *userRepositoryImpl) UpdateProfileURL(ctx context.Context, userID int64, profileURL string) error {
+// RestoreUser undoes a soft delete or deactivation.
+func (r *userRepositoryImpl) RestoreUser(ctx context.Context, id int64) error {
 	query := `
 		UPDATE users
-		SET profile_url = $1, updated_at = $2
-		WHERE id = $3`
-
-	updatedAt := time.Now()
+		SET is_active = TRUE, deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1`
 
-	res, err := r.db.ExecContext(ctx, query, profileURL, updatedAt, userID)
+	res, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("failed to update user profile URL: %w", err)
+		return fmt.Errorf("failed to restore user: %w", err)
 	}
 
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected for profile URL update: %w", err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("user with ID %d not found for profile URL update", userID)
+		return apperrors.NotFound("user with ID %d not found", id)
 	}
 	return nil
 }
 
-func (r *userRepositoryImpl) GetStudentCountsByClass(ctx context.Context) (map[string]int, error) {
-	query := `
-        SELECT TRIM(class) as class, COUNT(id) as total
-        FROM users
-        WHERE role = 'mahasiswa'
-        GROUP BY TRIM(class)
-    `
+// PurgeDeletedUsers permanently removes users soft-deleted before the given
+// time. Their progress, quiz attempts and tokens go with them through ON
+// DELETE CASCADE.
+func (r *userRepositoryImpl) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
 
-	var counts []dto.StudentCount
-	err := r.db.SelectContext(ctx, &counts, query)
+	purged, err := res.RowsAffected()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get student counts by class: %w", err)
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
 	}
+	return purged, nil
+}
 
-	studentCountsMap := make(map[string]int)
-	for _, count := range counts {
-		studentCountsMap[count.Class] = count.Total
+func (r *userRepositoryImpl) GetAllUsers(ctx context.Context) ([]*models.User, error) {
+	query := `
+		SELECT ` + userColumnsSQL + `
+		FROM ` + userFromSQL
+
+	users := []*models.User{}
+	err := r.db.SelectContext(ctx, &users, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all users: %w", err)
 	}
 
-	return studentCountsMap, nil
+	return users, nil
 }
 
-func (r *userRepositoryImpl) GetAdmins(ctx context.Context) ([]*models.User, error) {
+// UpdateProfileURL sets the user's profile_url and returns the value it
+// replaced, so the caller can clean up the previous file.
+func (r *userRepositoryImpl) UpdateProfileURL(ctx context.Context, userID int64, profileURL string) (*string, error) {
 	query := `
-		SELECT id, name, email, password, class, birthday, role, profile_url, created_at, updated_at
-		FROM users
-		WHERE role = 'admin'`
+		UPDATE users u
+		SET profile_url = $1, updated_at = $2
+		FROM (SELECT id, profile_url FROM users WHERE id = $3 FOR UPDATE) previous
+		WHERE u.id = previous.id
+		RETURNING previous.profile_url`
+
+	updatedAt := time.Now()
 
-	admins := []*models.User{}
-	err := r.db.SelectContext(ctx, &admins, query)
+	var previous *string
+	err := r.db.GetContext(ctx, &previous, query, profileURL, updatedAt, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get admin users: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("user with ID %d not found", userID)
+		}
+		return nil, fmt.Errorf("failed to update user profile URL: %w", err)
+	}
+	return previous, nil
+}
+
+// GetProfileURLs returns every non-empty profile_url.
+func (r *userRepositoryImpl) GetProfileURLs(ctx context.Context) ([]string, error) {
+	query := `SELECT profile_url FROM users WHERE profile_url IS NOT NULL AND profile_url <> ''`
+
+	profileURLs := []string{}
+	err := r.db.SelectContext(ctx, &profileURLs, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile URLs: %w", err)
+	}
+	return profileURLs, nil
+}
+
+// GetStudentCountsByClass counts active students per class, ordered by
+// class name with students without a class last. A non-nil classIDs limits
+// the count to those classes.
+func (r *userRepositoryImpl) GetStudentCountsByClass(ctx context.Context, classIDs []int64) ([]dto.ClassStudentCount, error) {
+	query := `
+		SELECT u.class_id, COALESCE(c.name, '') AS class_name, COUNT(u.id) AS total
+		FROM ` + userFromSQL + `
+		WHERE u.role = 'mahasiswa' AND ` + activeUserSQL + `
+		  AND ($1::bigint[] IS NULL OR u.class_id = ANY($1))
+		GROUP BY u.class_id, c.name
+		ORDER BY c.name NULLS LAST, u.class_id`
+
+	counts := []dto.ClassStudentCount{}
+	err := r.db.SelectContext(ctx, &counts, query, pq.Array(classIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student counts by class: %w", err)
 	}
-	return admins, nil
+	return counts, nil
 }
 
 func (r *userRepositoryImpl) GetTotalAdmins(ctx context.Context) (int, error) {
 	query := `
-		SELECT COUNT(id)
-		FROM users
-		WHERE role = 'admin'`
+		SELECT COUNT(u.id)
+		FROM users u
+		WHERE u.role = 'admin' AND ` + activeUserSQL
 
 	var total int
 	err := r.db.GetContext(ctx, &total, query)
@@ -226,16 +348,183 @@ func (r *userRepositoryImpl) GetTotalAdmins(ctx context.Context) (int, error) {
 	return total, nil
 }
 
-func (r *userRepositoryImpl) GetMahasiswaUsers(ctx context.Context) ([]*models.User, error) {
+// firstAdminLockKey serializes first-run admin bootstrap requests so two
+// concurrent callers cannot both observe "no admins" and create one each.
+const firstAdminLockKey int64 = 0x61646d696e
+
+// CreateFirstAdmin inserts the user only if no admin exists yet. It reports
+// false without inserting when an admin is already present.
+func (r *userRepositoryImpl) CreateFirstAdmin(ctx context.Context, user *models.User) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin admin bootstrap: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, firstAdminLockKey); err != nil {
+		return false, fmt.Errorf("failed to acquire admin bootstrap lock: %w", err)
+	}
+
+	var total int
+	if err := tx.GetContext(ctx, &total, `SELECT COUNT(id) FROM users WHERE role = 'admin' AND deleted_at IS NULL`); err != nil {
+		return false, fmt.Errorf("failed to get total admin count: %w", err)
+	}
+	if total > 0 {
+		return false, nil
+	}
+
 	query := `
-		SELECT id, name, email, password, class, birthday, role, profile_url, created_at, updated_at
-		FROM users
-		WHERE role = 'mahasiswa'`
+		INSERT INTO users (name, email, password, class_id, birthday, role, profile_url, is_active, created_at, updated_at)
+		VALUES (:name, :email, :password, :class_id, :birthday, :role, :profile_url, :is_active, :created_at, :updated_at)
+		RETURNING id, created_at, updated_at`
 
-	mahasiswaUsers := []*models.User{}
-	err := r.db.SelectContext(ctx, &mahasiswaUsers, query)
+	user.IsActive = true
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	stmt, err := tx.PrepareNamedContext(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get mahasiswa users: %w", err)
+		return false, fmt.Errorf("failed to prepare named query for admin bootstrap: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.GetContext(ctx, user, user); err != nil {
+		return false, dbError(err, "failed to create first admin")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit admin bootstrap: %w", err)
+	}
+	return true, nil
+}
+
+// ListUsers returns one page of users matching the filter together with the
+// total number of matching users.
+func (r *userRepositoryImpl) ListUsers(ctx context.Context, filter UserListFilter) ([]*models.User, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	status, ok := userStatusConditions[filter.Status]
+	if !ok {
+		status = activeUserSQL
+	}
+	if status != "" {
+		conditions = append(conditions, status)
+	}
+
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		conditions = append(conditions, fmt.Sprintf("u.role = $%d", len(args)))
+	}
+	if filter.ClassID != 0 {
+		args = append(args, filter.ClassID)
+		conditions = append(conditions, fmt.Sprintf("u.class_id = $%d", len(args)))
+	}
+	if filter.ClassIDs != nil {
+		args = append(args, pq.Array(filter.ClassIDs))
+		conditions = append(conditions, fmt.Sprintf("u.class_id = ANY($%d)", len(args)))
+	}
+	if filter.Search != "" {
+		args = append(args, "%"+escapeLike(strings.TrimSpace(filter.Search))+"%")
+		conditions = append(conditions, fmt.Sprintf("(u.name ILIKE $%d OR u.email ILIKE $%d)", len(args), len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(u.id) FROM users u `+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	sortColumn, ok := userSortColumns[filter.Sort]
+	if !ok {
+		sortColumn = userSortColumns["name"]
+	}
+	direction := "ASC"
+	if strings.EqualFold(filter.Order, "desc") {
+		direction = "DESC"
 	}
-	return mahasiswaUsers, nil
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT `+userColumnsSQL+`
+		FROM `+userFromSQL+`
+		%s
+		ORDER BY %s %s NULLS LAST, u.id %s
+		LIMIT $%d OFFSET $%d`, where, sortColumn, direction, direction, len(args)-1, len(args))
+
+	users := []*models.User{}
+	if err := r.db.SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// escapeLike escapes the LIKE wildcards in user input so a search for "50%"
+// matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// GetExistingEmails reports which of the given emails are already registered.
+// Keys of the returned map are lower-cased.
+func (r *userRepositoryImpl) GetExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(emails) == 0 {
+		return existing, nil
+	}
+
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+
+	var found []string
+	err := r.db.SelectContext(ctx, &found, `SELECT LOWER(email) FROM users WHERE LOWER(email) = ANY($1)`, pq.Array(lowered))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing emails: %w", err)
+	}
+	for _, email := range found {
+		existing[email] = true
+	}
+	return existing, nil
+}
+
+// CreateUsers inserts all users in a single transaction; either every user is
+// created or none are.
+func (r *userRepositoryImpl) CreateUsers(ctx context.Context, users []*models.User) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin bulk user creation: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO users (name, email, password, class_id, birthday, role, profile_url, is_active, created_at, updated_at)
+		VALUES (:name, :email, :password, :class_id, :birthday, :role, :profile_url, :is_active, :created_at, :updated_at)
+		RETURNING id, created_at, updated_at`
+
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare named query for bulk user creation: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, user := range users {
+		user.IsActive = true
+		user.CreatedAt = now
+		user.UpdatedAt = now
+		if err := stmt.GetContext(ctx, user, user); err != nil {
+			return dbError(err, "failed to create user %s", user.Email)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit bulk user creation: %w", err)
+	}
+	return nil
 }
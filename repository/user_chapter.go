@@ -4,19 +4,36 @@ import (
 	"be-education/dto"
 	"be-education/models"
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type UserChapterRepository interface {
 	CreateUserChapter(ctx context.Context, userChapter *models.UserChapter) error
 	GetUserQuizScoresByUserID(ctx context.Context, userID int64) ([]*dto.UserChapterQuizScoreResponse, error)
 	CheckUserChapterCompletion(ctx context.Context, userID int64, chapterID int) (bool, error)
-	GetAllUsersWithAllChapterScores(ctx context.Context) ([]*dto.UserChapterScore, error)
+	GetAllUsersWithAllChapterScores(ctx context.Context, classIDs []int64) ([]*dto.UserChapterScore, error)
+	StreamGradebookScores(ctx context.Context, classIDs []int64, fn func(*dto.GradebookScore) error) error
+	RecordQuizAttempt(ctx context.Context, attempt *models.QuizAttempt, maxAttempts int) (bool, error)
+	GetQuizAttempts(ctx context.Context, userID, chapterID int64) ([]*models.QuizAttempt, error)
+	GetUserChapterScore(ctx context.Context, userID, chapterID int64) (*float64, error)
+	RecomputeChapterScores(ctx context.Context, chapterID int64) error
 }
 
+// resolvedScoreSQL picks the chapter score out of the grouped quiz_attempts
+// rows (alias a) according to the chapter's score policy (alias c).
+const resolvedScoreSQL = `
+	CASE c.score_policy
+		WHEN 'latest' THEN (ARRAY_AGG(a.score ORDER BY a.attempt_number DESC))[1]
+		WHEN 'first' THEN (ARRAY_AGG(a.score ORDER BY a.attempt_number ASC))[1]
+		WHEN 'average' THEN ROUND(AVG(a.score)::numeric, 2)::double precision
+		ELSE MAX(a.score)
+	END`
+
 type userChapterImpl struct {
 	db *sqlx.DB
 }
@@ -42,7 +59,10 @@ func (r *userChapterImpl) CreateUserChapter(ctx context.Context, userChapter *mo
 	query := `
 		INSERT INTO user_chapters (user_id, chapter_id, completed_at, quiz_score, created_at, updated_at)
 		VALUES (:user_id, :chapter_id, :completed_at, :quiz_score, :created_at, :updated_at)
-		RETURNING id, created_at, updated_at`
+		ON CONFLICT (user_id, chapter_id) DO UPDATE
+		SET completed_at = COALESCE(user_chapters.completed_at, EXCLUDED.completed_at),
+		    updated_at = EXCLUDED.updated_at
+		RETURNING id, quiz_score, created_at, updated_at`
 
 	userChapter.CreatedAt = time.Now()
 	userChapter.UpdatedAt = time.Now()
@@ -55,7 +75,7 @@ func (r *userChapterImpl) CreateUserChapter(ctx context.Context, userChapter *mo
 
 	err = stmt.GetContext(ctx, userChapter, userChapter)
 	if err != nil {
-		return fmt.Errorf("failed to create user chapter: %w", err)
+		return dbError(err, "failed to create user chapter")
 	}
 	return nil
 }
@@ -82,28 +102,191 @@ func (r *userChapterImpl) GetUserQuizScoresByUserID(ctx context.Context, userID
 	return quizScores, nil
 }
 
-func (r *userChapterImpl) GetAllUsersWithAllChapterScores(ctx context.Context) ([]*dto.UserChapterScore, error) {
+// GetAllUsersWithAllChapterScores returns every student with their chapter
+// scores. A non-nil classIDs limits the result to students of those classes.
+func (r *userChapterImpl) GetAllUsersWithAllChapterScores(ctx context.Context, classIDs []int64) ([]*dto.UserChapterScore, error) {
 	query := `
         SELECT
             u.id as user_id,
             u.name as user_name,
-            COALESCE(u.class, '') as user_class, -- Handle nullable class
+            u.class_id,
+            COALESCE(c.name, '') as user_class, -- Handle students without a class
             COALESCE(uc.chapter_id, 0) as chapter_id, -- COALESCE chapter_id to 0 if NULL
-            COALESCE(uc.quiz_score, 0.0) as score -- Use COALESCE to get 0.0 if quiz_score is NULL
+            uc.quiz_score as score -- NULL for chapters without a quiz score
         FROM
             users u
+        LEFT JOIN
+            classes c ON c.id = u.class_id
         LEFT JOIN
             user_chapters uc ON u.id = uc.user_id
         WHERE
             u.role = 'mahasiswa' -- Assuming we only care about 'mahasiswa' for this summary
+            AND u.is_active AND u.deleted_at IS NULL
+            AND ($1::bigint[] IS NULL OR u.class_id = ANY($1))
         ORDER BY
             u.id, uc.chapter_id
     `
 
 	var results []*dto.UserChapterScore
-	err := r.db.SelectContext(ctx, &results, query)
+	err := r.db.SelectContext(ctx, &results, query, pq.Array(classIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all users with chapter scores: %w", err)
 	}
 	return results, nil
 }
+
+// StreamGradebookScores calls fn for every (student, chapter score) row,
+// ordered by class, then student name, so callers can emit class footers as
+// they go without buffering the whole result. classIDs filters like in
+// GetAllUsersWithAllChapterScores.
+func (r *userChapterImpl) StreamGradebookScores(ctx context.Context, classIDs []int64, fn func(*dto.GradebookScore) error) error {
+	query := `
+		SELECT
+			u.id AS user_id,
+			u.name AS user_name,
+			u.class_id,
+			COALESCE(c.name, '') AS user_class,
+			COALESCE(uc.chapter_id, 0) AS chapter_id,
+			uc.quiz_score AS score
+		FROM users u
+		LEFT JOIN classes c ON c.id = u.class_id
+		LEFT JOIN user_chapters uc ON uc.user_id = u.id
+		WHERE u.role = 'mahasiswa'
+		  AND u.is_active AND u.deleted_at IS NULL
+		  AND ($1::bigint[] IS NULL OR u.class_id = ANY($1))
+		ORDER BY c.name NULLS LAST, u.class_id, u.name, u.id`
+
+	rows, err := r.db.QueryxContext(ctx, query, pq.Array(classIDs))
+	if err != nil {
+		return fmt.Errorf("failed to query gradebook scores: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var score dto.GradebookScore
+		if err := rows.StructScan(&score); err != nil {
+			return fmt.Errorf("failed to scan gradebook score: %w", err)
+		}
+		if err := fn(&score); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read gradebook scores: %w", err)
+	}
+	return nil
+}
+
+// RecordQuizAttempt stores the next numbered attempt and refreshes the
+// policy-resolved score in user_chapters. Submissions of the same user are
+// serialized by locking the user row. It reports false without writing
+// anything when maxAttempts (if positive) has already been reached.
+func (r *userChapterImpl) RecordQuizAttempt(ctx context.Context, attempt *models.QuizAttempt, maxAttempts int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin quiz attempt: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, attempt.UserID); err != nil {
+		return false, fmt.Errorf("failed to lock user for quiz attempt: %w", err)
+	}
+
+	var previous int
+	countQuery := `
+		SELECT COALESCE(MAX(attempt_number), 0)
+		FROM quiz_attempts
+		WHERE user_id = $1 AND chapter_id = $2`
+	if err := tx.GetContext(ctx, &previous, countQuery, attempt.UserID, attempt.ChapterID); err != nil {
+		return false, fmt.Errorf("failed to count quiz attempts: %w", err)
+	}
+	if maxAttempts > 0 && previous >= maxAttempts {
+		return false, nil
+	}
+
+	attempt.AttemptNumber = previous + 1
+
+	insertQuery := `
+		INSERT INTO quiz_attempts (user_id, chapter_id, quiz_id, attempt_number, score, earned_points, total_points, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+	err = tx.GetContext(ctx, &attempt.ID, insertQuery,
+		attempt.UserID, attempt.ChapterID, attempt.QuizID, attempt.AttemptNumber,
+		attempt.Score, attempt.EarnedPoints, attempt.TotalPoints, attempt.SubmittedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert quiz attempt: %w", err)
+	}
+
+	upsertQuery := `
+		INSERT INTO user_chapters (user_id, chapter_id, completed_at, quiz_score, created_at, updated_at)
+		SELECT a.user_id, a.chapter_id, MIN(a.submitted_at), ` + resolvedScoreSQL + `, NOW(), NOW()
+		FROM quiz_attempts a
+		JOIN chapters c ON c.id = a.chapter_id
+		WHERE a.user_id = $1 AND a.chapter_id = $2
+		GROUP BY a.user_id, a.chapter_id, c.score_policy
+		ON CONFLICT (user_id, chapter_id) DO UPDATE
+		SET quiz_score = EXCLUDED.quiz_score,
+		    completed_at = COALESCE(user_chapters.completed_at, EXCLUDED.completed_at),
+		    updated_at = EXCLUDED.updated_at`
+	if _, err := tx.ExecContext(ctx, upsertQuery, attempt.UserID, attempt.ChapterID); err != nil {
+		return false, fmt.Errorf("failed to update resolved chapter score: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit quiz attempt: %w", err)
+	}
+	return true, nil
+}
+
+func (r *userChapterImpl) GetQuizAttempts(ctx context.Context, userID, chapterID int64) ([]*models.QuizAttempt, error) {
+	query := `
+		SELECT id, user_id, chapter_id, quiz_id, attempt_number, score, earned_points, total_points, submitted_at
+		FROM quiz_attempts
+		WHERE user_id = $1 AND chapter_id = $2
+		ORDER BY attempt_number`
+
+	attempts := []*models.QuizAttempt{}
+	err := r.db.SelectContext(ctx, &attempts, query, userID, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+func (r *userChapterImpl) GetUserChapterScore(ctx context.Context, userID, chapterID int64) (*float64, error) {
+	query := `
+		SELECT quiz_score
+		FROM user_chapters
+		WHERE user_id = $1 AND chapter_id = $2`
+
+	var score *float64
+	err := r.db.GetContext(ctx, &score, query, userID, chapterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user chapter score: %w", err)
+	}
+	return score, nil
+}
+
+// RecomputeChapterScores re-resolves every stored score of the chapter, e.g.
+// after its score policy changed.
+func (r *userChapterImpl) RecomputeChapterScores(ctx context.Context, chapterID int64) error {
+	query := `
+		UPDATE user_chapters uc
+		SET quiz_score = resolved.score, updated_at = NOW()
+		FROM (
+			SELECT a.user_id, a.chapter_id, ` + resolvedScoreSQL + ` AS score
+			FROM quiz_attempts a
+			JOIN chapters c ON c.id = a.chapter_id
+			WHERE a.chapter_id = $1
+			GROUP BY a.user_id, a.chapter_id, c.score_policy
+		) resolved
+		WHERE uc.user_id = resolved.user_id AND uc.chapter_id = resolved.chapter_id`
+
+	if _, err := r.db.ExecContext(ctx, query, chapterID); err != nil {
+		return fmt.Errorf("failed to recompute chapter scores: %w", err)
+	}
+	return nil
+}
@@ -3,43 +3,86 @@ package router
 import (
 	"be-education/config"
 	"be-education/handler"
+	"be-education/loginguard"
+	"be-education/mailer"
 	"be-education/middleware"
+	"be-education/models"
 	"be-education/repository"
 	"be-education/service"
+	"be-education/storage"
 	"be-education/utils"
 
-	"time"
+	"log/slog"
+	"os"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 )
 
-func InitRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
-	r := gin.Default()
+func InitRouter(db *sqlx.DB, cfg *config.Config, mail mailer.Mailer, files storage.Storage, loginGuard *loginguard.Guard, healthService service.HealthService) *gin.Engine {
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		slog.Error("Invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+
+	// The request ID comes first so every later log record carries it.
+	r.Use(middleware.RequestID(), middleware.AccessLog("/healthz", "/readyz"))
+	// Metrics wraps Recovery so requests that panic are observed with the
+	// recovered 500 status.
+	if cfg.Metrics.Enabled() {
+		r.Use(middleware.Metrics())
+	}
+	r.Use(middleware.Recovery())
+	// A separate metrics listener is started by main; only token-protected
+	// metrics are served on the API itself.
+	if cfg.Metrics.Addr == "" && cfg.Metrics.Token != "" {
+		r.GET("/metrics", gin.WrapH(middleware.MetricsHandler(cfg.Metrics.Token)))
+	}
+
+	r.Use(middleware.CORS(cfg.CORS))
 
-	// Tambahkan middleware CORS
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"}, // Ubah sesuai kebutuhan
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	r.Use(middleware.ErrorHandler())
 
-	r.Static("/uploads", "./uploads")
+	healthHandler := handler.NewHealthHandler(healthService)
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
 
-	jwtUtil := utils.NewJWTUtil(cfg.SecretKey)
+	jwtUtil := utils.NewJWTUtil(cfg.SecretKey, cfg.Token.AccessTTL)
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo, jwtUtil)
-	userHandler := handler.NewUserHandler(userService, cfg.Server.BaseURL)
+	classRepo := repository.NewClassRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	tokenService := service.NewTokenService(tokenRepo, userRepo, jwtUtil, cfg.Token.RefreshTTL)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	passwordResetService := service.NewPasswordResetService(userRepo, passwordResetRepo, tokenService, mail, cfg.PasswordReset.URL, cfg.PasswordReset.TTL)
+	authHandler := handler.NewAuthHandler(tokenService, passwordResetService)
+	permissionService := service.NewPermissionService(repository.NewPermissionRepository(db), cfg.PermissionCacheTTL)
+	userService := service.NewUserService(userRepo, classRepo, tokenService, permissionService, files, loginGuard)
+	userHandler := handler.NewUserHandler(userService)
+	studentImportService := service.NewStudentImportService(userRepo, classRepo, passwordResetService)
+	studentImportHandler := handler.NewStudentImportHandler(studentImportService)
+	classService := service.NewClassService(classRepo, userRepo)
+	classHandler := handler.NewClassHandler(classService)
+	permissionHandler := handler.NewPermissionHandler(permissionService)
 
+	chapterRepo := repository.NewChapterRepository(db)
 	userChapterRepo := repository.NewUserChapterRepository(db)
-	userChapterService := service.NewUserChapterService(userChapterRepo)
-	userChapterHandler := handler.NewUserChapterHandler(userChapterService)
+	chapterService := service.NewChapterService(chapterRepo, userChapterRepo)
+	chapterHandler := handler.NewChapterHandler(chapterService)
 
-	authMiddleware := middleware.NewAuthMiddleware(cfg)
+	userChapterService := service.NewUserChapterService(userChapterRepo, chapterRepo)
+	userChapterHandler := handler.NewUserChapterHandler(userChapterService, classService)
+
+	quizRepo := repository.NewQuizRepository(db)
+	quizService := service.NewQuizService(quizRepo, chapterRepo, userChapterRepo, userRepo)
+	quizHandler := handler.NewQuizHandler(quizService)
+
+	fileHandler := handler.NewFileHandler(files)
+
+	authMiddleware := middleware.NewAuthMiddleware(cfg, tokenService, classService, permissionService)
+
+	r.GET("/uploads/*key", fileHandler.ServeFile)
+	r.HEAD("/uploads/*key", fileHandler.ServeFile)
 
 	api := r.Group("/api/v1")
 	{
@@ -47,17 +90,71 @@ func InitRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 		{
 			users.POST("", userHandler.CreateMahasiswa)
 			users.GET("/profile", authMiddleware.Auth(), userHandler.GetProfile)
+			users.PATCH("/profile", authMiddleware.Auth(), userHandler.UpdateProfile)
+			users.POST("/profile/password", authMiddleware.Auth(), userHandler.ChangePassword)
 			users.POST("/profile/image", authMiddleware.Auth(), userHandler.UpdateProfileImage)
-			users.GET("/summary/students", authMiddleware.Auth(), authMiddleware.RequireRole("admin"), userHandler.GetStudentSummary)
-			users.GET("/summary/admins", authMiddleware.Auth(), authMiddleware.RequireRole("admin"), userHandler.GetAdminSummary)
-			users.POST("/admin", userHandler.CreateAdmin)
-			users.GET("/mahasiswa", authMiddleware.Auth(), authMiddleware.RequireRole("admin"), userHandler.GetMahasiswaUsers)
-			users.DELETE("/:id", authMiddleware.Auth(), authMiddleware.RequireRole("admin"), userHandler.DeleteUser)
+			users.GET("/summary/students", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersRead), userHandler.GetStudentSummary)
+			users.GET("/summary/admins", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermAdminsManage), userHandler.GetAdminSummary)
+			users.POST("/admin", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermAdminsManage), userHandler.CreateAdmin)
+			users.POST("/guru", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersCreate), userHandler.CreateGuru)
+			users.GET("/mahasiswa", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersRead), userHandler.GetMahasiswaUsers)
+			users.POST("/mahasiswa/import", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersCreate), studentImportHandler.ImportStudents)
+			users.PATCH("/:id", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersUpdate), userHandler.UpdateUser)
+			users.DELETE("/:id", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersDelete), userHandler.DeleteUser)
+			users.POST("/:id/deactivate", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersDeactivate), userHandler.DeactivateUser)
+			users.POST("/:id/restore", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersDeactivate), userHandler.RestoreUser)
+			users.POST("/:id/unlock", authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermUsersUnlock), userHandler.UnlockUser)
+		}
+
+		if cfg.SetupToken != "" {
+			api.POST("/setup/admin", middleware.RequireSetupToken(cfg.SetupToken), userHandler.SetupAdmin)
 		}
 
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", userHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/logout", authMiddleware.Auth(), authHandler.Logout)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+		}
+
+		classes := api.Group("/classes")
+		{
+			classes.Use(authMiddleware.Auth())
+			classes.GET("", classHandler.GetAllClasses)
+			classes.GET("/:id", classHandler.GetClass)
+			classes.POST("", authMiddleware.RequirePermission(models.PermClassesManage), classHandler.CreateClass)
+			classes.PATCH("/:id", authMiddleware.RequirePermission(models.PermClassesManage), classHandler.UpdateClass)
+			classes.DELETE("/:id", authMiddleware.RequirePermission(models.PermClassesManage), classHandler.DeleteClass)
+			classes.GET("/:id/teachers", authMiddleware.RequirePermission(models.PermUsersRead), classHandler.GetClassTeachers)
+			classes.PUT("/:id/teachers", authMiddleware.RequirePermission(models.PermClassesManage), classHandler.SetClassTeachers)
+		}
+
+		permissions := api.Group("/permissions")
+		{
+			permissions.Use(authMiddleware.Auth(), authMiddleware.RequirePermission(models.PermPermissionsManage))
+			permissions.GET("", permissionHandler.GetAllPermissions)
+			permissions.GET("/roles", permissionHandler.GetRolePermissions)
+			permissions.PUT("/roles/:role", permissionHandler.SetRolePermissions)
+		}
+
+		chapters := api.Group("/chapters")
+		{
+			chapters.Use(authMiddleware.Auth())
+			chapters.GET("", chapterHandler.GetAllChapters)
+			chapters.GET("/:id", chapterHandler.GetChapter)
+			chapters.POST("", authMiddleware.RequirePermission(models.PermChaptersManage), chapterHandler.CreateChapter)
+			chapters.PUT("/order", authMiddleware.RequirePermission(models.PermChaptersManage), chapterHandler.ReorderChapters)
+			chapters.PATCH("/:id", authMiddleware.RequirePermission(models.PermChaptersManage), chapterHandler.UpdateChapter)
+			chapters.DELETE("/:id", authMiddleware.RequirePermission(models.PermChaptersManage), chapterHandler.DeleteChapter)
+			chapters.GET("/:id/quiz", quizHandler.GetQuiz)
+			chapters.GET("/:id/quiz/answers", authMiddleware.RequirePermission(models.PermQuizzesReadAnswers), quizHandler.GetQuizWithAnswers)
+			chapters.PUT("/:id/quiz", authMiddleware.RequirePermission(models.PermChaptersManage), quizHandler.UpsertQuiz)
+			chapters.DELETE("/:id/quiz", authMiddleware.RequirePermission(models.PermChaptersManage), quizHandler.DeleteQuiz)
+			chapters.POST("/:id/quiz/submissions", quizHandler.SubmitQuiz)
+			chapters.GET("/:id/quiz/attempts", quizHandler.GetMyAttempts)
+			chapters.GET("/:id/quiz/attempts/:userId", authMiddleware.RequirePermission(models.PermScoresRead), quizHandler.GetUserAttempts)
 		}
 
 		userChapters := api.Group("/user-chapters")
@@ -66,7 +163,7 @@ func InitRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 			userChapters.POST("", userChapterHandler.CreateUserChapter)
 			userChapters.GET("", userChapterHandler.GetUserQuizScores)
 			userChapters.POST("/check-completion", userChapterHandler.CheckUserChapterCompletion)
-			userChapters.GET("/summary/all-scores", authMiddleware.RequireRole("admin"), userChapterHandler.GetAllUsersChapterScores)
+			userChapters.GET("/summary/all-scores", authMiddleware.RequirePermission(models.PermScoresReadAll), userChapterHandler.GetAllUsersChapterScores)
 		}
 	}
 
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/models"
+	"be-education/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrChapterNotFound      = apperrors.NotFound("chapter not found")
+	ErrChapterNameRequired  = apperrors.Validation("chapter name cannot be empty").WithField("name", "is required")
+	ErrChapterNameTaken     = apperrors.Conflict("a chapter with this name already exists")
+	ErrChapterInUse         = apperrors.Conflict("chapter already has student progress and cannot be deleted")
+	ErrInvalidChapterOrder  = apperrors.Validation("chapter order must list every chapter exactly once")
+	ErrChapterPositionRange = apperrors.Validation("chapter position is out of range").WithField("position", "is out of range")
+)
+
+type ChapterService interface {
+	CreateChapter(ctx context.Context, req *dto.CreateChapterRequest) (*models.Chapter, error)
+	GetChapterByID(ctx context.Context, id int64) (*models.Chapter, error)
+	GetAllChapters(ctx context.Context) ([]*models.Chapter, error)
+	UpdateChapter(ctx context.Context, id int64, req *dto.UpdateChapterRequest) (*models.Chapter, error)
+	DeleteChapter(ctx context.Context, id int64) error
+	ReorderChapters(ctx context.Context, chapterIDs []int64) ([]*models.Chapter, error)
+}
+
+type chapterServiceImpl struct {
+	chapterRepo     repository.ChapterRepository
+	userChapterRepo repository.UserChapterRepository
+}
+
+func NewChapterService(chapterRepo repository.ChapterRepository, userChapterRepo repository.UserChapterRepository) ChapterService {
+	return &chapterServiceImpl{chapterRepo: chapterRepo, userChapterRepo: userChapterRepo}
+}
+
+func (s *chapterServiceImpl) CreateChapter(ctx context.Context, req *dto.CreateChapterRequest) (*models.Chapter, error) {
+	name, err := s.validateName(ctx, req.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the position before inserting, so a rejected request does not
+	// leave the chapter behind at the end of the list.
+	if req.Position != nil {
+		chapters, err := s.GetAllChapters(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if *req.Position < 1 || *req.Position > len(chapters)+1 {
+			return nil, ErrChapterPositionRange
+		}
+	}
+
+	chapter := &models.Chapter{Name: name, ScorePolicy: models.ScorePolicyBest}
+	if req.ScorePolicy != nil {
+		chapter.ScorePolicy = *req.ScorePolicy
+	}
+	if req.MaxAttempts != nil {
+		chapter.MaxAttempts = *req.MaxAttempts
+	}
+	if err := s.chapterRepo.CreateChapter(ctx, chapter); err != nil {
+		return nil, fmt.Errorf("service failed to create chapter: %w", err)
+	}
+
+	if req.Position != nil && *req.Position != chapter.Position {
+		if err := s.moveChapter(ctx, chapter.ID, *req.Position); err != nil {
+			return nil, err
+		}
+		return s.GetChapterByID(ctx, chapter.ID)
+	}
+
+	return chapter, nil
+}
+
+func (s *chapterServiceImpl) GetChapterByID(ctx context.Context, id int64) (*models.Chapter, error) {
+	chapter, err := s.chapterRepo.GetChapterByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, ErrChapterNotFound
+		}
+		return nil, fmt.Errorf("service failed to get chapter: %w", err)
+	}
+	return chapter, nil
+}
+
+func (s *chapterServiceImpl) GetAllChapters(ctx context.Context) ([]*models.Chapter, error) {
+	chapters, err := s.chapterRepo.GetAllChapters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get chapters: %w", err)
+	}
+	return chapters, nil
+}
+
+func (s *chapterServiceImpl) UpdateChapter(ctx context.Context, id int64, req *dto.UpdateChapterRequest) (*models.Chapter, error) {
+	chapter, err := s.GetChapterByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name, err := s.validateName(ctx, *req.Name, id)
+		if err != nil {
+			return nil, err
+		}
+		chapter.Name = name
+	}
+
+	policyChanged := req.ScorePolicy != nil && *req.ScorePolicy != chapter.ScorePolicy
+	if req.ScorePolicy != nil {
+		chapter.ScorePolicy = *req.ScorePolicy
+	}
+	if req.MaxAttempts != nil {
+		chapter.MaxAttempts = *req.MaxAttempts
+	}
+
+	if req.Name != nil || req.ScorePolicy != nil || req.MaxAttempts != nil {
+		if err := s.chapterRepo.UpdateChapter(ctx, chapter); err != nil {
+			return nil, fmt.Errorf("service failed to update chapter: %w", err)
+		}
+	}
+
+	if policyChanged {
+		if err := s.userChapterRepo.RecomputeChapterScores(ctx, id); err != nil {
+			return nil, fmt.Errorf("service failed to recompute chapter scores: %w", err)
+		}
+	}
+
+	if req.Position != nil && *req.Position != chapter.Position {
+		if err := s.moveChapter(ctx, id, *req.Position); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetChapterByID(ctx, id)
+}
+
+func (s *chapterServiceImpl) DeleteChapter(ctx context.Context, id int64) error {
+	exists, err := s.chapterRepo.ChapterExists(ctx, id)
+	if err != nil {
+		return fmt.Errorf("service failed to check chapter: %w", err)
+	}
+	if !exists {
+		return ErrChapterNotFound
+	}
+
+	inUse, err := s.chapterRepo.HasUserChapters(ctx, id)
+	if err != nil {
+		return fmt.Errorf("service failed to check chapter usage: %w", err)
+	}
+	if inUse {
+		return ErrChapterInUse
+	}
+
+	if err := s.chapterRepo.DeleteChapter(ctx, id); err != nil {
+		return fmt.Errorf("service failed to delete chapter: %w", err)
+	}
+	return nil
+}
+
+func (s *chapterServiceImpl) ReorderChapters(ctx context.Context, chapterIDs []int64) ([]*models.Chapter, error) {
+	chapters, err := s.GetAllChapters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chapterIDs) != len(chapters) {
+		return nil, ErrInvalidChapterOrder
+	}
+
+	known := make(map[int64]bool, len(chapters))
+	for _, chapter := range chapters {
+		known[chapter.ID] = true
+	}
+	seen := make(map[int64]bool, len(chapterIDs))
+	for _, id := range chapterIDs {
+		if !known[id] || seen[id] {
+			return nil, ErrInvalidChapterOrder
+		}
+		seen[id] = true
+	}
+
+	if err := s.chapterRepo.ReorderChapters(ctx, chapterIDs); err != nil {
+		return nil, fmt.Errorf("service failed to reorder chapters: %w", err)
+	}
+
+	return s.GetAllChapters(ctx)
+}
+
+func (s *chapterServiceImpl) validateName(ctx context.Context, name string, excludeID int64) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrChapterNameRequired
+	}
+
+	taken, err := s.chapterRepo.ChapterNameExists(ctx, name, excludeID)
+	if err != nil {
+		return "", fmt.Errorf("service failed to validate chapter name: %w", err)
+	}
+	if taken {
+		return "", ErrChapterNameTaken
+	}
+	return name, nil
+}
+
+// moveChapter places the chapter at the given 1-based position and shifts the
+// chapters in between by one.
+func (s *chapterServiceImpl) moveChapter(ctx context.Context, id int64, position int) error {
+	chapters, err := s.GetAllChapters(ctx)
+	if err != nil {
+		return err
+	}
+	if position < 1 || position > len(chapters) {
+		return ErrChapterPositionRange
+	}
+
+	ordered := make([]int64, 0, len(chapters))
+	for _, chapter := range chapters {
+		if chapter.ID != id {
+			ordered = append(ordered, chapter.ID)
+		}
+	}
+	ordered = append(ordered[:position-1], append([]int64{id}, ordered[position-1:]...)...)
+
+	if err := s.chapterRepo.ReorderChapters(ctx, ordered); err != nil {
+		return fmt.Errorf("service failed to move chapter: %w", err)
+	}
+	return nil
+}
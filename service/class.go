@@ -0,0 +1,218 @@
+package service
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/models"
+	"be-education/repository"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrClassNotFound       = apperrors.NotFound("class not found")
+	ErrClassNameRequired   = apperrors.Validation("class name cannot be empty").WithField("name", "is required")
+	ErrClassInUse          = apperrors.Conflict("class still has students and cannot be deleted")
+	ErrClassOutOfScope     = apperrors.Forbidden("you are not assigned to this class")
+	ErrInvalidAcademicYear = apperrors.Validation("academic year must look like 2025/2026").WithField("academic_year", "must be two consecutive years, e.g. 2025/2026")
+)
+
+var academicYearPattern = regexp.MustCompile(`^(\d{4})/(\d{4})$`)
+
+type ClassService interface {
+	CreateClass(ctx context.Context, req *dto.CreateClassRequest) (*models.Class, error)
+	GetClassByID(ctx context.Context, scope *models.Scope, id int64) (*models.Class, error)
+	GetAllClasses(ctx context.Context, scope *models.Scope, academicYear string) ([]*models.Class, error)
+	UpdateClass(ctx context.Context, id int64, req *dto.UpdateClassRequest) (*models.Class, error)
+	DeleteClass(ctx context.Context, id int64) error
+	GetClassTeachers(ctx context.Context, scope *models.Scope, id int64) ([]dto.ClassTeacher, error)
+	SetClassTeachers(ctx context.Context, id int64, teacherIDs []int64) ([]dto.ClassTeacher, error)
+	GetTeacherClassIDs(ctx context.Context, userID int64) ([]int64, error)
+}
+
+type classServiceImpl struct {
+	classRepo repository.ClassRepository
+	userRepo  repository.UserRepository
+}
+
+func NewClassService(classRepo repository.ClassRepository, userRepo repository.UserRepository) ClassService {
+	return &classServiceImpl{classRepo: classRepo, userRepo: userRepo}
+}
+
+func (s *classServiceImpl) CreateClass(ctx context.Context, req *dto.CreateClassRequest) (*models.Class, error) {
+	class := &models.Class{}
+	if err := applyClassChanges(class, &req.Name, req.AcademicYear, req.HomeroomTeacher); err != nil {
+		return nil, err
+	}
+
+	if err := s.classRepo.CreateClass(ctx, class); err != nil {
+		return nil, fmt.Errorf("service failed to create class: %w", err)
+	}
+	return class, nil
+}
+
+// GetClassByID returns the class if it is within the caller's scope.
+func (s *classServiceImpl) GetClassByID(ctx context.Context, scope *models.Scope, id int64) (*models.Class, error) {
+	if !scope.CanAccessClass(&id) {
+		return nil, ErrClassOutOfScope
+	}
+	return s.getClass(ctx, id)
+}
+
+func (s *classServiceImpl) getClass(ctx context.Context, id int64) (*models.Class, error) {
+	class, err := s.classRepo.GetClassByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, ErrClassNotFound
+		}
+		return nil, fmt.Errorf("service failed to get class: %w", err)
+	}
+	return class, nil
+}
+
+func (s *classServiceImpl) GetAllClasses(ctx context.Context, scope *models.Scope, academicYear string) ([]*models.Class, error) {
+	classes, err := s.classRepo.GetAllClasses(ctx, strings.TrimSpace(academicYear), scope.ClassFilter())
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get classes: %w", err)
+	}
+	return classes, nil
+}
+
+func (s *classServiceImpl) UpdateClass(ctx context.Context, id int64, req *dto.UpdateClassRequest) (*models.Class, error) {
+	class, err := s.getClass(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyClassChanges(class, req.Name, req.AcademicYear, req.HomeroomTeacher); err != nil {
+		return nil, err
+	}
+
+	if err := s.classRepo.UpdateClass(ctx, class); err != nil {
+		return nil, fmt.Errorf("service failed to update class: %w", err)
+	}
+	return class, nil
+}
+
+func (s *classServiceImpl) DeleteClass(ctx context.Context, id int64) error {
+	if _, err := s.getClass(ctx, id); err != nil {
+		return err
+	}
+
+	inUse, err := s.classRepo.HasUsers(ctx, id)
+	if err != nil {
+		return fmt.Errorf("service failed to check class usage: %w", err)
+	}
+	if inUse {
+		return ErrClassInUse
+	}
+
+	if err := s.classRepo.DeleteClass(ctx, id); err != nil {
+		return fmt.Errorf("service failed to delete class: %w", err)
+	}
+	return nil
+}
+
+func (s *classServiceImpl) GetClassTeachers(ctx context.Context, scope *models.Scope, id int64) ([]dto.ClassTeacher, error) {
+	if _, err := s.GetClassByID(ctx, scope, id); err != nil {
+		return nil, err
+	}
+
+	teachers, err := s.classRepo.GetClassTeachers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get class teachers: %w", err)
+	}
+	return teachers, nil
+}
+
+// SetClassTeachers replaces the teachers of the class. Every ID must belong
+// to an active guru account.
+func (s *classServiceImpl) SetClassTeachers(ctx context.Context, id int64, teacherIDs []int64) ([]dto.ClassTeacher, error) {
+	if _, err := s.getClass(ctx, id); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool, len(teacherIDs))
+	unique := make([]int64, 0, len(teacherIDs))
+	for _, teacherID := range teacherIDs {
+		if seen[teacherID] {
+			continue
+		}
+		seen[teacherID] = true
+
+		user, err := s.userRepo.GetUserByID(ctx, teacherID)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return nil, fmt.Errorf("failed to retrieve user by ID %d: %w", teacherID, err)
+		}
+		if user == nil || user.Role != "guru" || !user.Active() {
+			return nil, apperrors.Validation("user with ID %d is not an active guru", teacherID).WithField("teacher_ids", "must reference active guru accounts")
+		}
+		unique = append(unique, teacherID)
+	}
+
+	if err := s.classRepo.SetClassTeachers(ctx, id, unique); err != nil {
+		return nil, fmt.Errorf("service failed to set class teachers: %w", err)
+	}
+
+	teachers, err := s.classRepo.GetClassTeachers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get class teachers: %w", err)
+	}
+	return teachers, nil
+}
+
+func (s *classServiceImpl) GetTeacherClassIDs(ctx context.Context, userID int64) ([]int64, error) {
+	classIDs, err := s.classRepo.GetTeacherClassIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get teacher classes: %w", err)
+	}
+	return classIDs, nil
+}
+
+// applyClassChanges validates and copies the given fields; nil fields are
+// left unchanged and empty optional fields are cleared.
+func applyClassChanges(class *models.Class, name, academicYear, homeroomTeacher *string) error {
+	if name != nil {
+		trimmed := strings.TrimSpace(*name)
+		if trimmed == "" {
+			return ErrClassNameRequired
+		}
+		class.Name = trimmed
+	}
+
+	if academicYear != nil {
+		year := strings.TrimSpace(*academicYear)
+		if year == "" {
+			class.AcademicYear = nil
+		} else {
+			if !validAcademicYear(year) {
+				return ErrInvalidAcademicYear
+			}
+			class.AcademicYear = &year
+		}
+	}
+
+	if homeroomTeacher != nil {
+		teacher := strings.TrimSpace(*homeroomTeacher)
+		if teacher == "" {
+			class.HomeroomTeacher = nil
+		} else {
+			class.HomeroomTeacher = &teacher
+		}
+	}
+	return nil
+}
+
+func validAcademicYear(year string) bool {
+	match := academicYearPattern.FindStringSubmatch(year)
+	if match == nil {
+		return false
+	}
+	start, _ := strconv.Atoi(match[1])
+	end, _ := strconv.Atoi(match[2])
+	return end == start+1
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"be-education/db"
+	"be-education/dto"
+	"be-education/storage"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// healthProbeDir holds the short-lived objects written by the storage check.
+// The profile image GC removes any the check failed to delete.
+const healthProbeDir = "healthcheck"
+
+// storageProbeInterval is how long a storage check result is reused. The
+// check writes an object, which /readyz must not do on every probe: it is
+// unauthenticated and, on S3, every write is billed.
+const storageProbeInterval = 30 * time.Second
+
+type HealthService interface {
+	// Ready runs the dependency checks concurrently, each bounded by the
+	// service's check timeout.
+	Ready(ctx context.Context) *dto.ReadinessReport
+	// StartDraining makes every later readiness check fail so load
+	// balancers stop routing new traffic during shutdown.
+	StartDraining()
+}
+
+type healthServiceImpl struct {
+	db       *sqlx.DB
+	files    storage.Storage
+	migrator *db.Migrator
+	timeout  time.Duration
+	draining atomic.Bool
+
+	storageMu        sync.Mutex
+	storageCheckedAt time.Time
+	storageErr       error
+}
+
+// NewHealthService checks the database, upload storage and, when migrator is
+// not nil, that every embedded migration has been applied.
+func NewHealthService(dbConn *sqlx.DB, files storage.Storage, migrator *db.Migrator, timeout time.Duration) HealthService {
+	return &healthServiceImpl{db: dbConn, files: files, migrator: migrator, timeout: timeout}
+}
+
+func (s *healthServiceImpl) StartDraining() {
+	s.draining.Store(true)
+}
+
+func (s *healthServiceImpl) Ready(ctx context.Context) *dto.ReadinessReport {
+	if s.draining.Load() {
+		return &dto.ReadinessReport{Ready: false, Draining: true, Checks: []dto.HealthCheck{}}
+	}
+
+	type check struct {
+		name string
+		run  func(ctx context.Context) (string, error)
+	}
+	checks := []check{
+		{"database", s.checkDatabase},
+		{"storage", s.checkStorage},
+	}
+	if s.migrator != nil {
+		checks = append(checks, check{"migrations", s.checkMigrations})
+	}
+
+	report := &dto.ReadinessReport{Ready: true, Checks: make([]dto.HealthCheck, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+
+			start := time.Now()
+			detail, err := c.run(checkCtx)
+			result := dto.HealthCheck{
+				Name:      c.name,
+				Status:    HealthStatusOK,
+				Detail:    detail,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = HealthStatusFail
+				if errors.Is(err, context.DeadlineExceeded) {
+					result.Detail = "timed out"
+				}
+				slog.WarnContext(ctx, "Readiness check failed", "check", c.name, "error", err)
+			}
+			report.Checks[i] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range report.Checks {
+		if c.Status != HealthStatusOK {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+func (s *healthServiceImpl) checkDatabase(ctx context.Context) (string, error) {
+	return "", s.db.PingContext(ctx)
+}
+
+// checkStorage reports the result of the last storage probe, running a new
+// one once the previous result is older than storageProbeInterval.
+// Concurrent checks wait for a single probe instead of each writing.
+func (s *healthServiceImpl) checkStorage(ctx context.Context) (string, error) {
+	s.storageMu.Lock()
+	defer s.storageMu.Unlock()
+
+	if s.storageCheckedAt.IsZero() || time.Since(s.storageCheckedAt) >= storageProbeInterval {
+		s.storageErr = s.probeStorage(ctx)
+		s.storageCheckedAt = time.Now()
+	}
+	return "", s.storageErr
+}
+
+// probeStorage writes and removes a small object, which catches read-only
+// volumes and revoked bucket credentials that a read would not.
+func (s *healthServiceImpl) probeStorage(ctx context.Context) error {
+	key := fmt.Sprintf("%s/%s", healthProbeDir, uuid.New().String())
+	probe := []byte("ok")
+	if err := s.files.Put(ctx, key, bytes.NewReader(probe), int64(len(probe)), "text/plain"); err != nil {
+		return fmt.Errorf("failed to write probe object: %w", err)
+	}
+	if err := s.files.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete probe object: %w", err)
+	}
+	return nil
+}
+
+func (s *healthServiceImpl) checkMigrations(ctx context.Context) (string, error) {
+	pending, err := s.migrator.Pending(ctx)
+	if err != nil {
+		return "", err
+	}
+	if pending > 0 {
+		detail := fmt.Sprintf("%d pending migrations", pending)
+		return detail, errors.New(detail)
+	}
+	return "", nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"be-education/apperrors"
+	"be-education/mailer"
+	"be-education/models"
+	"be-education/repository"
+	"be-education/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+var ErrInvalidResetToken = apperrors.Validation("invalid or expired password reset token")
+
+type PasswordResetService interface {
+	RequestReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+type passwordResetServiceImpl struct {
+	userRepo     repository.UserRepository
+	resetRepo    repository.PasswordResetRepository
+	tokenService TokenService
+	mailer       mailer.Mailer
+	resetURL     string
+	tokenTTL     time.Duration
+}
+
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetRepository,
+	tokenService TokenService,
+	mailer mailer.Mailer,
+	resetURL string,
+	tokenTTL time.Duration,
+) PasswordResetService {
+	return &passwordResetServiceImpl{
+		userRepo:     userRepo,
+		resetRepo:    resetRepo,
+		tokenService: tokenService,
+		mailer:       mailer,
+		resetURL:     resetURL,
+		tokenTTL:     tokenTTL,
+	}
+}
+
+// RequestReset issues a reset token and mails the link to the user. Unknown
+// emails and deactivated accounts are silently ignored so the endpoint cannot
+// be used to discover which addresses have accounts.
+func (s *passwordResetServiceImpl) RequestReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+	if !user.Active() {
+		return nil
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	resetToken := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.tokenTTL),
+	}
+	if err := s.resetRepo.CreateResetToken(ctx, resetToken); err != nil {
+		return fmt.Errorf("service failed to store password reset token: %w", err)
+	}
+
+	link, err := s.buildResetLink(token)
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s and can only be used once. If you did not request this, you can ignore this email.\n",
+			user.Name, link, s.tokenTTL),
+	}
+
+	// Deliver in the background so the response time does not reveal
+	// whether the email belongs to an existing account.
+	go func() {
+		// Keep the request's log attributes but not its cancellation.
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if err := s.mailer.Send(sendCtx, msg); err != nil {
+			slog.ErrorContext(sendCtx, "Failed to send password reset email", "target_user_id", user.ID, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *passwordResetServiceImpl) ResetPassword(ctx context.Context, token, newPassword string) error {
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	userID, err := s.resetRepo.ResetPassword(ctx, utils.HashToken(token), hashedPassword)
+	if err != nil {
+		return fmt.Errorf("service failed to reset password: %w", err)
+	}
+	if userID == 0 {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.tokenService.RevokeAllUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("password was reset but existing sessions could not be revoked: %w", err)
+	}
+	return nil
+}
+
+func (s *passwordResetServiceImpl) buildResetLink(token string) (string, error) {
+	u, err := url.Parse(s.resetURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid password reset URL %q: %w", s.resetURL, err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
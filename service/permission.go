@@ -0,0 +1,167 @@
+package service
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/models"
+	"be-education/repository"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrAdminPermissionLockout = apperrors.Conflict("the admin role must keep the %s permission", models.PermPermissionsManage)
+
+type PermissionService interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+	GetAllPermissions(ctx context.Context) ([]*models.Permission, error)
+	GetRolePermissions(ctx context.Context) ([]dto.RolePermissions, error)
+	SetRolePermissions(ctx context.Context, role string, permissions []string) (*dto.RolePermissions, error)
+}
+
+type permissionServiceImpl struct {
+	permissionRepo repository.PermissionRepository
+	cacheTTL       time.Duration
+
+	mu       sync.RWMutex
+	grants   map[string]map[string]bool
+	loadedAt time.Time
+}
+
+// NewPermissionService caches the role-permission mappings for cacheTTL so
+// permission checks do not hit the database on every request. Changes made
+// through this service apply immediately; changes made by other instances
+// apply once the cache expires. A cacheTTL of 0 disables the cache.
+func NewPermissionService(permissionRepo repository.PermissionRepository, cacheTTL time.Duration) PermissionService {
+	return &permissionServiceImpl{permissionRepo: permissionRepo, cacheTTL: cacheTTL}
+}
+
+func (s *permissionServiceImpl) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	grants, err := s.loadGrants(ctx)
+	if err != nil {
+		return false, err
+	}
+	return grants[role][permission], nil
+}
+
+// loadGrants returns the cached mappings, reloading them once they expire.
+func (s *permissionServiceImpl) loadGrants(ctx context.Context) (map[string]map[string]bool, error) {
+	s.mu.RLock()
+	grants, fresh := s.grants, s.cacheFresh()
+	s.mu.RUnlock()
+	if fresh {
+		return grants, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Another request may have reloaded while we waited for the lock.
+	if s.cacheFresh() {
+		return s.grants, nil
+	}
+
+	rows, err := s.permissionRepo.GetRolePermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load role permissions: %w", err)
+	}
+
+	grants = make(map[string]map[string]bool)
+	for _, row := range rows {
+		if grants[row.Role] == nil {
+			grants[row.Role] = make(map[string]bool)
+		}
+		grants[row.Role][row.Permission] = true
+	}
+	s.grants, s.loadedAt = grants, time.Now()
+	return grants, nil
+}
+
+// cacheFresh must be called with s.mu held.
+func (s *permissionServiceImpl) cacheFresh() bool {
+	return s.grants != nil && s.cacheTTL > 0 && time.Since(s.loadedAt) < s.cacheTTL
+}
+
+func (s *permissionServiceImpl) invalidate() {
+	s.mu.Lock()
+	s.grants = nil
+	s.mu.Unlock()
+}
+
+func (s *permissionServiceImpl) GetAllPermissions(ctx context.Context) ([]*models.Permission, error) {
+	permissions, err := s.permissionRepo.GetAllPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// GetRolePermissions lists the permissions of every role, including roles
+// without any. It always reads the database so admins see the current state.
+func (s *permissionServiceImpl) GetRolePermissions(ctx context.Context) ([]dto.RolePermissions, error) {
+	rows, err := s.permissionRepo.GetRolePermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get role permissions: %w", err)
+	}
+
+	byRole := make(map[string][]string)
+	for _, row := range rows {
+		byRole[row.Role] = append(byRole[row.Role], row.Permission)
+	}
+
+	roles := make([]dto.RolePermissions, len(models.Roles))
+	for i, role := range models.Roles {
+		permissions := byRole[role]
+		if permissions == nil {
+			permissions = []string{}
+		}
+		roles[i] = dto.RolePermissions{Role: role, Permissions: permissions}
+	}
+	return roles, nil
+}
+
+// SetRolePermissions replaces the permissions of a role. The admin role can
+// never lose permissions.manage, so the mappings stay editable.
+func (s *permissionServiceImpl) SetRolePermissions(ctx context.Context, role string, permissions []string) (*dto.RolePermissions, error) {
+	if !slices.Contains(models.Roles, role) {
+		return nil, apperrors.NotFound("role %q not found", role)
+	}
+
+	known, err := s.permissionRepo.GetAllPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get permissions: %w", err)
+	}
+	knownNames := make(map[string]bool, len(known))
+	for _, permission := range known {
+		knownNames[permission.Name] = true
+	}
+
+	granted := make([]string, 0, len(permissions))
+	var unknown []string
+	for _, permission := range permissions {
+		permission = strings.TrimSpace(permission)
+		switch {
+		case !knownNames[permission]:
+			unknown = append(unknown, permission)
+		case !slices.Contains(granted, permission):
+			granted = append(granted, permission)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, apperrors.Validation("unknown permissions: %s", strings.Join(unknown, ", ")).WithField("permissions", "contains unknown permissions")
+	}
+	if role == "admin" && !slices.Contains(granted, models.PermPermissionsManage) {
+		return nil, ErrAdminPermissionLockout
+	}
+
+	if err := s.permissionRepo.SetRolePermissions(ctx, role, granted); err != nil {
+		return nil, fmt.Errorf("service failed to set role permissions: %w", err)
+	}
+	s.invalidate()
+
+	sort.Strings(granted)
+	return &dto.RolePermissions{Role: role, Permissions: granted}, nil
+}
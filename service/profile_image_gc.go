@@ -0,0 +1,86 @@
+package service
+
+import (
+	"be-education/dto"
+	"be-education/repository"
+	"be-education/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+// ProfileImageGCService deletes stored profile images that no user
+// references anymore.
+type ProfileImageGCService interface {
+	Sweep(ctx context.Context, dryRun bool) (*dto.ImageGCReport, error)
+}
+
+type profileImageGCServiceImpl struct {
+	userRepo    repository.UserRepository
+	files       storage.Storage
+	gracePeriod time.Duration
+}
+
+// NewProfileImageGCService returns a sweeper that leaves files younger than
+// gracePeriod alone: an upload is stored before profile_url points at it.
+func NewProfileImageGCService(userRepo repository.UserRepository, files storage.Storage, gracePeriod time.Duration) ProfileImageGCService {
+	return &profileImageGCServiceImpl{userRepo: userRepo, files: files, gracePeriod: gracePeriod}
+}
+
+// Sweep lists every file under the profile image prefix and deletes those
+// not referenced by any users.profile_url, or only reports them when dryRun
+// is set. Objects the readiness probe failed to delete are swept as well.
+// Individual delete failures are collected in the report.
+func (s *profileImageGCServiceImpl) Sweep(ctx context.Context, dryRun bool) (*dto.ImageGCReport, error) {
+	// Files are listed before profile_url is read: a file uploaded in
+	// between is then either too young or already referenced.
+	var objects []*storage.Object
+	for _, prefix := range []string{profileImageDir + "/", healthProbeDir + "/"} {
+		err := s.files.List(ctx, prefix, func(object *storage.Object) error {
+			objects = append(objects, object)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+	}
+
+	profileURLs, err := s.userRepo.GetProfileURLs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile URLs: %w", err)
+	}
+
+	referenced := make(map[string]bool)
+	for _, profileURL := range profileURLs {
+		for _, key := range profileImageKeys(profileURL) {
+			referenced[key] = true
+		}
+	}
+
+	report := &dto.ImageGCReport{DryRun: dryRun, Scanned: len(objects), Orphans: []dto.ImageGCOrphan{}}
+	cutoff := time.Now().Add(-s.gracePeriod)
+
+	for _, object := range objects {
+		if referenced[object.Key] {
+			report.Referenced++
+			continue
+		}
+		if object.ModTime.After(cutoff) {
+			continue
+		}
+
+		report.Orphans = append(report.Orphans, dto.ImageGCOrphan{Key: object.Key, Size: object.Size, ModTime: object.ModTime})
+		if dryRun {
+			continue
+		}
+
+		if err := s.files.Delete(ctx, object.Key); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			continue
+		}
+		report.Deleted++
+		report.FreedBytes += object.Size
+	}
+
+	return report, nil
+}
@@ -0,0 +1,435 @@
+package service
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/metrics"
+	"be-education/models"
+	"be-education/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrQuizNotFound       = apperrors.NotFound("quiz not found")
+	ErrMaxAttemptsReached = apperrors.Conflict("maximum number of quiz attempts reached")
+)
+
+type QuizService interface {
+	GetQuizForStudent(ctx context.Context, chapterID int64) (*dto.QuizResponse, error)
+	GetQuizWithAnswers(ctx context.Context, chapterID int64) (*dto.QuizResponse, error)
+	UpsertQuiz(ctx context.Context, chapterID int64, req *dto.UpsertQuizRequest) (*dto.QuizResponse, error)
+	DeleteQuiz(ctx context.Context, chapterID int64) error
+	SubmitQuiz(ctx context.Context, userID, chapterID int64, req *dto.SubmitQuizRequest) (*dto.QuizResultResponse, error)
+	GetAttemptHistory(ctx context.Context, scope *models.Scope, userID, chapterID int64) (*dto.QuizAttemptHistoryResponse, error)
+}
+
+type quizServiceImpl struct {
+	quizRepo        repository.QuizRepository
+	chapterRepo     repository.ChapterRepository
+	userChapterRepo repository.UserChapterRepository
+	userRepo        repository.UserRepository
+}
+
+func NewQuizService(quizRepo repository.QuizRepository, chapterRepo repository.ChapterRepository, userChapterRepo repository.UserChapterRepository, userRepo repository.UserRepository) QuizService {
+	return &quizServiceImpl{quizRepo: quizRepo, chapterRepo: chapterRepo, userChapterRepo: userChapterRepo, userRepo: userRepo}
+}
+
+func (s *quizServiceImpl) GetQuizForStudent(ctx context.Context, chapterID int64) (*dto.QuizResponse, error) {
+	quiz, questions, err := s.loadQuiz(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	return buildQuizResponse(quiz, questions, false), nil
+}
+
+func (s *quizServiceImpl) GetQuizWithAnswers(ctx context.Context, chapterID int64) (*dto.QuizResponse, error) {
+	quiz, questions, err := s.loadQuiz(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	return buildQuizResponse(quiz, questions, true), nil
+}
+
+func (s *quizServiceImpl) UpsertQuiz(ctx context.Context, chapterID int64, req *dto.UpsertQuizRequest) (*dto.QuizResponse, error) {
+	exists, err := s.chapterRepo.ChapterExists(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to check chapter: %w", err)
+	}
+	if !exists {
+		return nil, ErrChapterNotFound
+	}
+
+	questions := make([]*models.QuizQuestion, len(req.Questions))
+	for i, input := range req.Questions {
+		question, err := buildQuestion(i+1, input)
+		if err != nil {
+			return nil, err
+		}
+		questions[i] = question
+	}
+
+	quiz := &models.Quiz{
+		ChapterID:   chapterID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+	}
+	if err := s.quizRepo.SaveQuiz(ctx, quiz, questions); err != nil {
+		return nil, fmt.Errorf("service failed to save quiz: %w", err)
+	}
+
+	return buildQuizResponse(quiz, questions, true), nil
+}
+
+func (s *quizServiceImpl) DeleteQuiz(ctx context.Context, chapterID int64) error {
+	err := s.quizRepo.DeleteQuizByChapterID(ctx, chapterID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return ErrQuizNotFound
+		}
+		return fmt.Errorf("service failed to delete quiz: %w", err)
+	}
+	return nil
+}
+
+// SubmitQuiz grades the answers against the stored answer key and records the
+// result as a new attempt. Unanswered questions earn no points. The chapter
+// score is then resolved from all attempts using the chapter's score policy.
+func (s *quizServiceImpl) SubmitQuiz(ctx context.Context, userID, chapterID int64, req *dto.SubmitQuizRequest) (*dto.QuizResultResponse, error) {
+	chapter, err := s.getChapter(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	quiz, questions, err := s.loadQuiz(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	answers, err := indexAnswers(questions, req.Answers)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.QuizResultResponse{
+		QuizID:         quiz.ID,
+		ChapterID:      chapterID,
+		ScorePolicy:    chapter.ScorePolicy,
+		TotalQuestions: len(questions),
+		Questions:      make([]dto.QuizQuestionResult, 0, len(questions)),
+	}
+
+	for _, q := range questions {
+		result.TotalPoints += q.Points
+
+		correct := false
+		if answer, ok := answers[q.ID]; ok {
+			correct = gradeQuestion(q, answer)
+		}
+
+		earned := 0.0
+		if correct {
+			earned = q.Points
+			result.CorrectCount++
+		}
+		result.EarnedPoints += earned
+		result.Questions = append(result.Questions, dto.QuizQuestionResult{
+			QuestionID:   q.ID,
+			Correct:      correct,
+			EarnedPoints: earned,
+		})
+	}
+
+	if result.TotalPoints > 0 {
+		result.Score = math.Round(result.EarnedPoints/result.TotalPoints*10000) / 100
+	}
+	result.CompletedAt = time.Now()
+
+	attempt := &models.QuizAttempt{
+		UserID:       userID,
+		ChapterID:    chapterID,
+		QuizID:       &quiz.ID,
+		Score:        result.Score,
+		EarnedPoints: result.EarnedPoints,
+		TotalPoints:  result.TotalPoints,
+		SubmittedAt:  result.CompletedAt,
+	}
+	recorded, err := s.userChapterRepo.RecordQuizAttempt(ctx, attempt, chapter.MaxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to record quiz attempt: %w", err)
+	}
+	if !recorded {
+		return nil, ErrMaxAttemptsReached
+	}
+	metrics.QuizSubmitted()
+	result.AttemptNumber = attempt.AttemptNumber
+	result.AttemptsRemaining = attemptsRemaining(chapter.MaxAttempts, attempt.AttemptNumber)
+
+	chapterScore, err := s.userChapterRepo.GetUserChapterScore(ctx, userID, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get chapter score: %w", err)
+	}
+	result.ChapterScore = chapterScore
+
+	return result, nil
+}
+
+// GetAttemptHistory returns the user's attempts at the chapter quiz. Users
+// may always read their own history; other users must be within the
+// caller's scope.
+func (s *quizServiceImpl) GetAttemptHistory(ctx context.Context, scope *models.Scope, userID, chapterID int64) (*dto.QuizAttemptHistoryResponse, error) {
+	if scope.UserID != userID {
+		user, err := s.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve user by ID %d: %w", userID, err)
+		}
+		if err := checkUserInScope(scope, user); err != nil {
+			return nil, err
+		}
+	}
+
+	chapter, err := s.getChapter(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.userChapterRepo.GetQuizAttempts(ctx, userID, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get quiz attempts: %w", err)
+	}
+
+	chapterScore, err := s.userChapterRepo.GetUserChapterScore(ctx, userID, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to get chapter score: %w", err)
+	}
+
+	return &dto.QuizAttemptHistoryResponse{
+		UserID:            userID,
+		ChapterID:         chapterID,
+		ScorePolicy:       chapter.ScorePolicy,
+		MaxAttempts:       chapter.MaxAttempts,
+		AttemptsRemaining: attemptsRemaining(chapter.MaxAttempts, len(attempts)),
+		ChapterScore:      chapterScore,
+		Attempts:          attempts,
+	}, nil
+}
+
+func (s *quizServiceImpl) getChapter(ctx context.Context, chapterID int64) (*models.Chapter, error) {
+	chapter, err := s.chapterRepo.GetChapterByID(ctx, chapterID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, ErrChapterNotFound
+		}
+		return nil, fmt.Errorf("service failed to get chapter: %w", err)
+	}
+	return chapter, nil
+}
+
+// attemptsRemaining returns nil for chapters without an attempt limit.
+func attemptsRemaining(maxAttempts, used int) *int {
+	if maxAttempts <= 0 {
+		return nil
+	}
+	remaining := maxAttempts - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
+
+func (s *quizServiceImpl) loadQuiz(ctx context.Context, chapterID int64) (*models.Quiz, []*models.QuizQuestion, error) {
+	quiz, err := s.quizRepo.GetQuizByChapterID(ctx, chapterID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, nil, ErrQuizNotFound
+		}
+		return nil, nil, fmt.Errorf("service failed to get quiz: %w", err)
+	}
+
+	questions, err := s.quizRepo.GetQuestionsByQuizID(ctx, quiz.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service failed to get quiz questions: %w", err)
+	}
+	return quiz, questions, nil
+}
+
+func buildQuestion(number int, input dto.QuizQuestionInput) (*models.QuizQuestion, error) {
+	question := &models.QuizQuestion{
+		Type:   input.Type,
+		Prompt: strings.TrimSpace(input.Prompt),
+		Points: 1,
+	}
+	if input.Points != nil {
+		question.Points = *input.Points
+	}
+	if question.Prompt == "" {
+		return nil, apperrors.Validation("invalid quiz: question %d has an empty prompt", number)
+	}
+
+	switch input.Type {
+	case models.QuestionTypeSingleChoice, models.QuestionTypeMultipleChoice:
+		if len(input.AcceptedAnswers) > 0 {
+			return nil, apperrors.Validation("invalid quiz: question %d is a choice question and cannot have accepted answers", number)
+		}
+		if len(input.Options) < 2 {
+			return nil, apperrors.Validation("invalid quiz: question %d needs at least two options", number)
+		}
+		correct := 0
+		for _, option := range input.Options {
+			if option.IsCorrect {
+				correct++
+			}
+			question.Options = append(question.Options, models.QuizOption{
+				Text:      strings.TrimSpace(option.Text),
+				IsCorrect: option.IsCorrect,
+			})
+		}
+		if input.Type == models.QuestionTypeSingleChoice && correct != 1 {
+			return nil, apperrors.Validation("invalid quiz: question %d must have exactly one correct option", number)
+		}
+		if input.Type == models.QuestionTypeMultipleChoice && correct == 0 {
+			return nil, apperrors.Validation("invalid quiz: question %d must have at least one correct option", number)
+		}
+	case models.QuestionTypeShortAnswer:
+		if len(input.Options) > 0 {
+			return nil, apperrors.Validation("invalid quiz: question %d is a short-answer question and cannot have options", number)
+		}
+		for _, accepted := range input.AcceptedAnswers {
+			if normalizeShortAnswer(accepted) == "" {
+				continue
+			}
+			question.Options = append(question.Options, models.QuizOption{
+				Text:      strings.TrimSpace(accepted),
+				IsCorrect: true,
+			})
+		}
+		if len(question.Options) == 0 {
+			return nil, apperrors.Validation("invalid quiz: question %d needs at least one accepted answer", number)
+		}
+	default:
+		return nil, apperrors.Validation("invalid quiz: question %d has unsupported type %q", number, input.Type)
+	}
+
+	return question, nil
+}
+
+func buildQuizResponse(quiz *models.Quiz, questions []*models.QuizQuestion, includeAnswers bool) *dto.QuizResponse {
+	response := &dto.QuizResponse{
+		ID:          quiz.ID,
+		ChapterID:   quiz.ChapterID,
+		Title:       quiz.Title,
+		Description: quiz.Description,
+		Questions:   make([]dto.QuizQuestionResponse, 0, len(questions)),
+	}
+
+	for _, q := range questions {
+		response.TotalPoints += q.Points
+		item := dto.QuizQuestionResponse{
+			ID:     q.ID,
+			Type:   q.Type,
+			Prompt: q.Prompt,
+			Points: q.Points,
+		}
+
+		if q.Type == models.QuestionTypeShortAnswer {
+			if includeAnswers {
+				for _, o := range q.Options {
+					item.AcceptedAnswers = append(item.AcceptedAnswers, o.Text)
+				}
+			}
+		} else {
+			for _, o := range q.Options {
+				option := dto.QuizOptionResponse{ID: o.ID, Text: o.Text}
+				if includeAnswers {
+					isCorrect := o.IsCorrect
+					option.IsCorrect = &isCorrect
+				}
+				item.Options = append(item.Options, option)
+			}
+		}
+
+		response.Questions = append(response.Questions, item)
+	}
+
+	return response
+}
+
+func indexAnswers(questions []*models.QuizQuestion, inputs []dto.QuizAnswerInput) (map[int64]dto.QuizAnswerInput, error) {
+	byID := make(map[int64]*models.QuizQuestion, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+
+	answers := make(map[int64]dto.QuizAnswerInput, len(inputs))
+	for _, answer := range inputs {
+		q, ok := byID[answer.QuestionID]
+		if !ok {
+			return nil, apperrors.Validation("invalid quiz answer: question %d does not belong to this quiz", answer.QuestionID)
+		}
+		if _, dup := answers[answer.QuestionID]; dup {
+			return nil, apperrors.Validation("invalid quiz answer: question %d was answered more than once", answer.QuestionID)
+		}
+
+		if q.Type != models.QuestionTypeShortAnswer {
+			valid := make(map[int64]bool, len(q.Options))
+			for _, o := range q.Options {
+				valid[o.ID] = true
+			}
+			for _, optionID := range answer.OptionIDs {
+				if !valid[optionID] {
+					return nil, apperrors.Validation("invalid quiz answer: option %d does not belong to question %d", optionID, q.ID)
+				}
+			}
+		}
+
+		answers[answer.QuestionID] = answer
+	}
+	return answers, nil
+}
+
+// gradeQuestion is all-or-nothing: a single-choice question needs its one
+// correct option, a multiple-choice question needs exactly the set of correct
+// options, and a short answer must match an accepted answer after case and
+// whitespace normalization.
+func gradeQuestion(q *models.QuizQuestion, answer dto.QuizAnswerInput) bool {
+	switch q.Type {
+	case models.QuestionTypeSingleChoice, models.QuestionTypeMultipleChoice:
+		selected := make(map[int64]bool, len(answer.OptionIDs))
+		for _, id := range answer.OptionIDs {
+			selected[id] = true
+		}
+		if q.Type == models.QuestionTypeSingleChoice && len(selected) != 1 {
+			return false
+		}
+		for _, o := range q.Options {
+			if o.IsCorrect != selected[o.ID] {
+				return false
+			}
+		}
+		return true
+	case models.QuestionTypeShortAnswer:
+		if answer.Text == nil {
+			return false
+		}
+		given := normalizeShortAnswer(*answer.Text)
+		if given == "" {
+			return false
+		}
+		for _, o := range q.Options {
+			if normalizeShortAnswer(o.Text) == given {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func normalizeShortAnswer(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
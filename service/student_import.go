@@ -0,0 +1,373 @@
+package service
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/models"
+	"be-education/repository"
+	"be-education/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxImportRows caps a single upload so one request cannot tie up the server
+// hashing passwords for hours.
+const MaxImportRows = 2000
+
+// importColumns maps accepted header spellings (English and Indonesian) to
+// the canonical column name.
+var importColumns = map[string]string{
+	"name":          "name",
+	"nama":          "name",
+	"email":         "email",
+	"e-mail":        "email",
+	"class":         "class",
+	"kelas":         "class",
+	"birthday":      "birthday",
+	"birth_date":    "birthday",
+	"tanggal_lahir": "birthday",
+	"tanggal lahir": "birthday",
+	"password":      "password",
+}
+
+type StudentImportService interface {
+	ImportStudents(ctx context.Context, file io.Reader, filename string, query *dto.StudentImportQuery) (*dto.StudentImportResult, error)
+}
+
+type studentImportServiceImpl struct {
+	userRepo             repository.UserRepository
+	classRepo            repository.ClassRepository
+	passwordResetService PasswordResetService
+}
+
+func NewStudentImportService(userRepo repository.UserRepository, classRepo repository.ClassRepository, passwordResetService PasswordResetService) StudentImportService {
+	return &studentImportServiceImpl{userRepo: userRepo, classRepo: classRepo, passwordResetService: passwordResetService}
+}
+
+type importRow struct {
+	number    int
+	user      *models.User
+	className string
+	password  string
+	generated bool
+}
+
+// ImportStudents validates every row of the roster and, unless this is a dry
+// run, creates the valid rows as mahasiswa accounts in one transaction.
+// Invalid rows are reported and skipped.
+func (s *studentImportServiceImpl) ImportStudents(ctx context.Context, file io.Reader, filename string, query *dto.StudentImportQuery) (*dto.StudentImportResult, error) {
+	if query.PasswordMode == "" {
+		query.PasswordMode = dto.PasswordModeGenerate
+	}
+
+	records, err := utils.ReadSpreadsheet(file, filename)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnsupportedSpreadsheet) {
+			return nil, apperrors.Validation("%s", err.Error()).WithField("file", "must be a .csv or .xlsx file")
+		}
+		return nil, apperrors.Validation("could not read the uploaded file").Wrap(err)
+	}
+	if len(records) == 0 {
+		return nil, apperrors.Validation("the uploaded file is empty")
+	}
+
+	columns, err := mapImportHeader(records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.StudentImportResult{
+		DryRun:   query.DryRun,
+		Errors:   []dto.StudentImportRowError{},
+		Accounts: []dto.StudentImportAccount{},
+	}
+
+	var rows []*importRow
+	seenEmails := make(map[string]int)
+	for i, record := range records[1:] {
+		rowNumber := i + 2
+		if isBlankRecord(record) {
+			continue
+		}
+		result.TotalRows++
+		if result.TotalRows > MaxImportRows {
+			return nil, apperrors.Validation("the file has more than %d rows, split it into smaller uploads", MaxImportRows)
+		}
+
+		row, rowErrs := parseImportRow(rowNumber, record, columns)
+		if row != nil {
+			key := strings.ToLower(row.user.Email)
+			if first, ok := seenEmails[key]; ok {
+				rowErrs = append(rowErrs, dto.StudentImportRowError{Row: rowNumber, Field: "email", Message: fmt.Sprintf("duplicates the email on row %d", first)})
+			} else {
+				seenEmails[key] = rowNumber
+			}
+		}
+
+		if len(rowErrs) > 0 {
+			result.Errors = append(result.Errors, rowErrs...)
+			result.InvalidRows++
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	rows, err = s.resolveClasses(ctx, rows, result)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.rejectExistingEmails(ctx, rows, result)
+	if err != nil {
+		return nil, err
+	}
+	result.ValidRows = len(rows)
+
+	if query.DryRun || len(rows) == 0 {
+		for _, row := range rows {
+			result.Accounts = append(result.Accounts, importAccount(row))
+		}
+		return result, nil
+	}
+
+	if err := assignPasswords(rows); err != nil {
+		return nil, err
+	}
+
+	users := make([]*models.User, len(rows))
+	for i, row := range rows {
+		users[i] = row.user
+	}
+	if err := s.userRepo.CreateUsers(ctx, users); err != nil {
+		return nil, fmt.Errorf("service failed to import students: %w", err)
+	}
+	result.Imported = len(users)
+
+	for _, row := range rows {
+		account := importAccount(row)
+		if row.generated {
+			if query.PasswordMode == dto.PasswordModeResetLink {
+				if err := s.passwordResetService.RequestReset(ctx, row.user.Email); err != nil {
+					slog.ErrorContext(ctx, "Failed to send reset link to imported student", "email", row.user.Email, "error", err)
+				} else {
+					account.ResetLinkSent = true
+				}
+			} else {
+				account.InitialPassword = row.password
+			}
+		}
+		result.Accounts = append(result.Accounts, account)
+	}
+
+	return result, nil
+}
+
+// resolveClasses maps the class names of the roster onto existing classes.
+// Rows naming an unknown class are rejected rather than creating the class
+// implicitly, so a typo cannot split a class in two.
+func (s *studentImportServiceImpl) resolveClasses(ctx context.Context, rows []*importRow, result *dto.StudentImportResult) ([]*importRow, error) {
+	classes := make(map[string]*models.Class)
+
+	valid := rows[:0]
+	for _, row := range rows {
+		if row.className == "" {
+			valid = append(valid, row)
+			continue
+		}
+
+		key := strings.ToLower(row.className)
+		class, ok := classes[key]
+		if !ok {
+			var err error
+			class, err = s.classRepo.GetClassByName(ctx, row.className)
+			if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+				return nil, fmt.Errorf("service failed to look up class %q: %w", row.className, err)
+			}
+			classes[key] = class
+		}
+
+		if class == nil {
+			result.Errors = append(result.Errors, dto.StudentImportRowError{Row: row.number, Field: "class", Message: fmt.Sprintf("class %q does not exist", row.className)})
+			result.InvalidRows++
+			continue
+		}
+		row.user.ClassID, row.user.ClassName = &class.ID, &class.Name
+		valid = append(valid, row)
+	}
+	return valid, nil
+}
+
+func (s *studentImportServiceImpl) rejectExistingEmails(ctx context.Context, rows []*importRow, result *dto.StudentImportResult) ([]*importRow, error) {
+	emails := make([]string, len(rows))
+	for i, row := range rows {
+		emails[i] = row.user.Email
+	}
+
+	existing, err := s.userRepo.GetExistingEmails(ctx, emails)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to check existing students: %w", err)
+	}
+
+	valid := rows[:0]
+	for _, row := range rows {
+		if existing[strings.ToLower(row.user.Email)] {
+			result.Errors = append(result.Errors, dto.StudentImportRowError{Row: row.number, Field: "email", Message: "email is already registered"})
+			result.InvalidRows++
+			continue
+		}
+		valid = append(valid, row)
+	}
+	return valid, nil
+}
+
+func mapImportHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, cell := range header {
+		name, ok := importColumns[strings.ToLower(strings.TrimSpace(cell))]
+		if !ok {
+			continue
+		}
+		if _, dup := columns[name]; dup {
+			return nil, apperrors.Validation("column %q appears more than once in the header", name)
+		}
+		columns[name] = i
+	}
+
+	for _, required := range []string{"name", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, apperrors.Validation("the header row must contain a %q column", required)
+		}
+	}
+	return columns, nil
+}
+
+func parseImportRow(rowNumber int, record []string, columns map[string]int) (*importRow, []dto.StudentImportRowError) {
+	rawCell := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+	// Spreadsheet cells often carry stray spaces, so values are trimmed;
+	// only the password is taken as written.
+	cell := func(name string) string {
+		return strings.TrimSpace(rawCell(name))
+	}
+
+	var errs []dto.StudentImportRowError
+	fail := func(field, message string) {
+		errs = append(errs, dto.StudentImportRowError{Row: rowNumber, Field: field, Message: message})
+	}
+
+	user := &models.User{Role: "mahasiswa"}
+
+	user.Name = cell("name")
+	if user.Name == "" {
+		fail("name", "is required")
+	}
+
+	user.Email = cell("email")
+	if user.Email == "" {
+		fail("email", "is required")
+	} else if addr, err := mail.ParseAddress(user.Email); err != nil || addr.Address != user.Email {
+		fail("email", "must be a valid email address")
+	}
+
+	className := cell("class")
+
+	if birthday := cell("birthday"); birthday != "" {
+		t, err := utils.ParseSpreadsheetDate(birthday)
+		if err != nil {
+			fail("birthday", err.Error())
+		} else if t.After(time.Now()) {
+			fail("birthday", "cannot be in the future")
+		} else {
+			user.Birthday = &t
+		}
+	}
+
+	password := rawCell("password")
+	if strings.TrimSpace(password) == "" {
+		password = ""
+	} else if password != strings.TrimSpace(password) {
+		fail("password", "must not start or end with spaces")
+	} else if len(password) < 6 {
+		fail("password", "must be at least 6 characters")
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &importRow{number: rowNumber, user: user, className: className, password: password}, nil
+}
+
+// assignPasswords generates passwords for rows without one and hashes every
+// password. bcrypt is deliberately slow, so hashing is spread over the CPUs.
+func assignPasswords(rows []*importRow) error {
+	for _, row := range rows {
+		if row.password != "" {
+			continue
+		}
+		generated, err := utils.GenerateSecureToken(9)
+		if err != nil {
+			return err
+		}
+		row.password = generated
+		row.generated = true
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, runtime.NumCPU())
+	for _, row := range rows {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(row *importRow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			hashed, err := utils.HashPassword(row.password)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			row.user.Password = hashed
+		}(row)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func importAccount(row *importRow) dto.StudentImportAccount {
+	account := dto.StudentImportAccount{Row: row.number, Name: row.user.Name, Email: row.user.Email}
+	if row.user.ClassName != nil {
+		account.Class = *row.user.ClassName
+	}
+	return account
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package service
+
+import "testing"
+
+func TestParseImportRowTrimming(t *testing.T) {
+	columns := map[string]int{"name": 0, "email": 1, "class": 2, "password": 3}
+	tests := []struct {
+		name         string
+		record       []string
+		wantName     string
+		wantEmail    string
+		wantClass    string
+		wantPassword string
+		wantErrField string
+	}{
+		{
+			name:         "cells are trimmed, the password is kept as written",
+			record:       []string{"  Budi ", " budi@example.com\t", " 10A ", "pa ss word"},
+			wantName:     "Budi",
+			wantEmail:    "budi@example.com",
+			wantClass:    "10A",
+			wantPassword: "pa ss word",
+		},
+		{
+			name:         "blank password means none",
+			record:       []string{"Budi", "budi@example.com", "", "   "},
+			wantName:     "Budi",
+			wantEmail:    "budi@example.com",
+			wantPassword: "",
+		},
+		{
+			name:         "name of only spaces is missing",
+			record:       []string{"   ", "budi@example.com", "", ""},
+			wantErrField: "name",
+		},
+		{
+			name:         "password with spaces at the edges is rejected",
+			record:       []string{"Budi", "budi@example.com", "", " secret123 "},
+			wantErrField: "password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, errs := parseImportRow(2, tt.record, columns)
+			if tt.wantErrField != "" {
+				if len(errs) != 1 || errs[0].Field != tt.wantErrField {
+					t.Fatalf("errors = %+v, want one on %q", errs, tt.wantErrField)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %+v", errs)
+			}
+			if row.user.Name != tt.wantName || row.user.Email != tt.wantEmail || row.className != tt.wantClass || row.password != tt.wantPassword {
+				t.Errorf("got name %q, email %q, class %q, password %q", row.user.Name, row.user.Email, row.className, row.password)
+			}
+		})
+	}
+}
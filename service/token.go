@@ -0,0 +1,223 @@
+package service
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/models"
+	"be-education/repository"
+	"be-education/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken = apperrors.Unauthorized("invalid or expired refresh token")
+	ErrRefreshTokenReused  = apperrors.Unauthorized("refresh token has already been used")
+)
+
+type TokenService interface {
+	IssueTokenPair(ctx context.Context, user *models.User) (*dto.TokenResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*dto.TokenResponse, error)
+	Logout(ctx context.Context, claims *utils.Claims, refreshToken string) error
+	RevokeAllUserSessions(ctx context.Context, userID int64) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	IsUserActive(ctx context.Context, userID int64) (bool, error)
+	PurgeExpiredTokens(ctx context.Context) (int64, error)
+}
+
+type tokenServiceImpl struct {
+	tokenRepo  repository.TokenRepository
+	userRepo   repository.UserRepository
+	jwtUtil    *utils.JWTUtil
+	refreshTTL time.Duration
+}
+
+// NewTokenService issues access tokens through jwtUtil, which sets their
+// lifetime, and refresh tokens valid for refreshTTL.
+func NewTokenService(tokenRepo repository.TokenRepository, userRepo repository.UserRepository, jwtUtil *utils.JWTUtil, refreshTTL time.Duration) TokenService {
+	return &tokenServiceImpl{tokenRepo: tokenRepo, userRepo: userRepo, jwtUtil: jwtUtil, refreshTTL: refreshTTL}
+}
+
+// IssueTokenPair starts a new refresh token family for the user, typically
+// right after a successful login.
+func (s *tokenServiceImpl) IssueTokenPair(ctx context.Context, user *models.User) (*dto.TokenResponse, error) {
+	accessToken, refreshToken, record, err := s.newTokenPair(user, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.CreateRefreshToken(ctx, record); err != nil {
+		return nil, fmt.Errorf("service failed to store refresh token: %w", err)
+	}
+
+	return s.buildTokenResponse(accessToken, refreshToken), nil
+}
+
+// Refresh exchanges a refresh token for a new token pair in the same family.
+// Presenting a token that was already rotated or revoked is treated as theft
+// and revokes every token in its family.
+func (s *tokenServiceImpl) Refresh(ctx context.Context, refreshToken string) (*dto.TokenResponse, error) {
+	current, err := s.tokenRepo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("service failed to look up refresh token: %w", err)
+	}
+	if current == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if current.RevokedAt != nil {
+		slog.WarnContext(ctx, "Refresh token reuse detected, revoking family", "target_user_id", current.UserID, "family_id", current.FamilyID)
+		if err := s.tokenRepo.RevokeRefreshTokenFamily(ctx, current.FamilyID); err != nil {
+			return nil, fmt.Errorf("service failed to revoke refresh token family: %w", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, current.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("service failed to load refresh token owner: %w", err)
+	}
+	if !user.Active() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	accessToken, nextToken, record, err := s.newTokenPair(user, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := s.tokenRepo.RotateRefreshToken(ctx, current.ID, record)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to rotate refresh token: %w", err)
+	}
+	if !rotated {
+		slog.WarnContext(ctx, "Concurrent refresh token reuse detected, revoking family", "target_user_id", current.UserID, "family_id", current.FamilyID)
+		if err := s.tokenRepo.RevokeRefreshTokenFamily(ctx, current.FamilyID); err != nil {
+			return nil, fmt.Errorf("service failed to revoke refresh token family: %w", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return s.buildTokenResponse(accessToken, nextToken), nil
+}
+
+// Logout revokes the access token used for the request together with the
+// session it belongs to. A refresh token may be supplied explicitly for
+// clients that want to end a different session of the same user.
+func (s *tokenServiceImpl) Logout(ctx context.Context, claims *utils.Claims, refreshToken string) error {
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	} else {
+		expiresAt = time.Now().Add(s.jwtUtil.AccessTTL())
+	}
+
+	if err := s.tokenRepo.RevokeAccessToken(ctx, claims.ID, claims.UserID, expiresAt); err != nil {
+		return fmt.Errorf("service failed to revoke access token: %w", err)
+	}
+
+	if err := s.tokenRepo.RevokeSessionByAccessJTI(ctx, claims.UserID, claims.ID); err != nil {
+		return fmt.Errorf("service failed to revoke session: %w", err)
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	token, err := s.tokenRepo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return fmt.Errorf("service failed to look up refresh token: %w", err)
+	}
+	if token == nil || token.UserID != claims.UserID {
+		return ErrInvalidRefreshToken
+	}
+
+	if err := s.tokenRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		return fmt.Errorf("service failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (s *tokenServiceImpl) RevokeAllUserSessions(ctx context.Context, userID int64) error {
+	if err := s.tokenRepo.RevokeAllUserTokens(ctx, userID); err != nil {
+		return fmt.Errorf("service failed to revoke sessions for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// IsUserActive reports whether the token's user still exists and is neither
+// deactivated nor deleted. Access tokens stay valid until they expire, so
+// this is checked on every authenticated request.
+func (s *tokenServiceImpl) IsUserActive(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("service failed to load token owner: %w", err)
+	}
+	return user.Active(), nil
+}
+
+func (s *tokenServiceImpl) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	revoked, err := s.tokenRepo.IsAccessTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, fmt.Errorf("service failed to check token revocation: %w", err)
+	}
+	return revoked, nil
+}
+
+func (s *tokenServiceImpl) newTokenPair(user *models.User, familyID string) (string, string, *models.RefreshToken, error) {
+	accessToken, claims, err := s.jwtUtil.GenerateJWTToken(user)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to generate authentication token: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	record := &models.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        familyID,
+		TokenHash:       utils.HashToken(refreshToken),
+		AccessJTI:       claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:       time.Now().Add(s.refreshTTL),
+	}
+
+	return accessToken, refreshToken, record, nil
+}
+
+func (s *tokenServiceImpl) buildTokenResponse(accessToken, refreshToken string) *dto.TokenResponse {
+	return &dto.TokenResponse{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.jwtUtil.AccessTTL().Seconds()),
+		RefreshExpiresIn: int64(s.refreshTTL.Seconds()),
+	}
+}
+
+// PurgeExpiredTokens removes expired refresh tokens and revocation entries,
+// which would otherwise pile up with every refresh and logout.
+func (s *tokenServiceImpl) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	purged, err := s.tokenRepo.PurgeExpiredTokens(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("service failed to purge expired tokens: %w", err)
+	}
+	return purged, nil
+}
@@ -1,46 +1,206 @@
 package service
 
 import (
+	"be-education/apperrors"
 	"be-education/dto"
+	"be-education/imaging"
+	"be-education/loginguard"
+	"be-education/metrics"
 	"be-education/models"
 	user_repository "be-education/repository"
+	"be-education/storage"
 	"be-education/utils"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// profileImageDir is the storage key prefix of uploaded profile images.
+const profileImageDir = "profile_images"
+
+// MaxProfileImageSize is the largest accepted profile image upload in bytes.
+const MaxProfileImageSize = 5 << 20
+
+var (
+	profileImageLimits = imaging.Limits{MaxBytes: MaxProfileImageSize, MaxPixels: 40_000_000, MaxDimension: 10000}
+	// profileImageSizes are the square thumbnail sizes stored next to the
+	// original.
+	profileImageSizes = []int{64, 256, 512}
+)
+
+var (
+	ErrAdminAlreadyExists   = apperrors.Forbidden("an admin account already exists")
+	ErrInvalidCredentials   = apperrors.Unauthorized("invalid email or password")
+	ErrIncorrectPassword    = apperrors.Validation("current password is incorrect").WithField("current_password", "is incorrect")
+	ErrPasswordUnchanged    = apperrors.Validation("new password must differ from the current password").WithField("new_password", "must differ from the current password")
+	ErrOwnRoleChange        = apperrors.Forbidden("admins cannot change their own role")
+	ErrLastAdmin            = apperrors.Conflict("cannot remove the admin role from the last admin")
+	ErrAccountDeactivated   = apperrors.Forbidden("this account has been deactivated")
+	ErrOwnAccountRemoval    = apperrors.Forbidden("admins cannot deactivate or delete their own account")
+	ErrLastActiveAdmin      = apperrors.Conflict("the last active admin cannot be deactivated or deleted")
+	ErrUserOutOfScope       = apperrors.Forbidden("you can only manage students of your own classes")
+	ErrRoleChangeDenied     = apperrors.Forbidden("changing roles requires the %s permission", models.PermAdminsManage)
+	ErrAdminAccountDenied   = apperrors.Forbidden("managing admin accounts requires the %s permission", models.PermAdminsManage)
+	ErrAccountLocked        = apperrors.RateLimited("this account is temporarily locked after too many failed login attempts")
+	ErrTooManyLoginAttempts = apperrors.RateLimited("too many login attempts, please wait before trying again")
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, user *models.User) error
-	Login(ctx context.Context, email, password string) (string, error)
+	Login(ctx context.Context, email, password, clientIP string) (*dto.TokenResponse, error)
 	GetUserByID(ctx context.Context, id int64) (*dto.UserResponse, error)
-	UpdateProfileURL(ctx context.Context, userID int64, profileURL string) error
-	GetOverallStudentSummary(ctx context.Context) (*dto.StudentSummary, error)
+	UpdateProfileImage(ctx context.Context, userID int64, file io.Reader) (*dto.ProfileImage, error)
+	GetOverallStudentSummary(ctx context.Context, scope *models.Scope) (*dto.StudentSummary, error)
 	CreateAdmin(ctx context.Context, user *models.User) error
-	GetMahasiswaUsers(ctx context.Context) ([]*dto.UserResponse, error)
-	GetAdminSummary(ctx context.Context) (*dto.AdminSummary, error)
-	DeleteUser(ctx context.Context, id int64) error
+	CreateGuru(ctx context.Context, user *models.User) error
+	BootstrapAdmin(ctx context.Context, user *models.User) error
+	GetMahasiswaUsers(ctx context.Context, scope *models.Scope, query *dto.ListUsersQuery) (*dto.UserListResponse, error)
+	GetAdminSummary(ctx context.Context, query *dto.ListUsersQuery) (*dto.AdminSummary, error)
+	DeleteUser(ctx context.Context, scope *models.Scope, id int64) error
+	DeactivateUser(ctx context.Context, scope *models.Scope, id int64) error
+	RestoreUser(ctx context.Context, scope *models.Scope, id int64) (*dto.UserResponse, error)
+	UnlockUser(ctx context.Context, scope *models.Scope, id int64) error
+	PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error)
+	UpdateProfile(ctx context.Context, userID int64, req *dto.UpdateProfileRequest) (*dto.UserResponse, error)
+	ChangePassword(ctx context.Context, userID int64, req *dto.ChangePasswordRequest) (*dto.TokenResponse, error)
+	AdminUpdateUser(ctx context.Context, scope *models.Scope, userID int64, req *dto.AdminUpdateUserRequest) (*dto.UserResponse, error)
 }
 
 type userServiceImpl struct {
-	userRepo user_repository.UserRepository
-	jwtUtil  *utils.JWTUtil
+	userRepo     user_repository.UserRepository
+	classRepo    user_repository.ClassRepository
+	tokenService TokenService
+	permissions  PermissionService
+	files        storage.Storage
+	loginGuard   *loginguard.Guard
 }
 
-func (s *userServiceImpl) DeleteUser(ctx context.Context, id int64) error {
-	// _, err := s.userRepo.GetUserByID(ctx, id)
-	// if err != nil {
-	//     return fmt.Errorf("user with ID %d not found: %w", id, err)
-	// }
+// DeleteUser soft-deletes the user and signs them out everywhere. The account
+// can be restored until the purge job removes it.
+func (s *userServiceImpl) DeleteUser(ctx context.Context, scope *models.Scope, id int64) error {
+	if err := s.checkAccountRemoval(ctx, scope, id); err != nil {
+		return err
+	}
 
 	err := s.userRepo.DeleteUser(ctx, id)
 	if err != nil {
 		return fmt.Errorf("service failed to delete user with ID %d: %w", id, err)
 	}
+
+	if err := s.tokenService.RevokeAllUserSessions(ctx, id); err != nil {
+		return fmt.Errorf("user was deleted but existing sessions could not be revoked: %w", err)
+	}
+	return nil
+}
+
+func (s *userServiceImpl) DeactivateUser(ctx context.Context, scope *models.Scope, id int64) error {
+	if err := s.checkAccountRemoval(ctx, scope, id); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.SetUserActive(ctx, id, false); err != nil {
+		return fmt.Errorf("service failed to deactivate user with ID %d: %w", id, err)
+	}
+
+	if err := s.tokenService.RevokeAllUserSessions(ctx, id); err != nil {
+		return fmt.Errorf("user was deactivated but existing sessions could not be revoked: %w", err)
+	}
+	return nil
+}
+
+// RestoreUser reactivates a deactivated or soft-deleted user.
+func (s *userServiceImpl) RestoreUser(ctx context.Context, scope *models.Scope, id int64) (*dto.UserResponse, error) {
+	if scope.ClassRestricted() {
+		user, err := s.userRepo.GetUserByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve user by ID %d: %w", id, err)
+		}
+		if err := checkUserInScope(scope, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.RestoreUser(ctx, id); err != nil {
+		return nil, fmt.Errorf("service failed to restore user with ID %d: %w", id, err)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve user by ID %d: %w", id, err)
+	}
+	return s.toUserResponse(user), nil
+}
+
+// PurgeDeletedUsers permanently removes users deleted longer than retention
+// ago. Their profile images are left to the image sweep.
+func (s *userServiceImpl) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.userRepo.PurgeDeletedUsers(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("service failed to purge deleted users: %w", err)
+	}
+	return purged, nil
+}
+
+// checkAccountRemoval keeps admins from locking themselves, or everyone, out
+// of the admin area, a guru from reaching outside their classes, and callers
+// without admins.manage from removing admins.
+func (s *userServiceImpl) checkAccountRemoval(ctx context.Context, scope *models.Scope, id int64) error {
+	if scope.UserID == id {
+		return ErrOwnAccountRemoval
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user by ID %d: %w", id, err)
+	}
+	if err := checkUserInScope(scope, user); err != nil {
+		return err
+	}
+	if user.Role == "admin" {
+		if err := s.requireAdminsManage(ctx, scope, ErrAdminAccountDenied); err != nil {
+			return err
+		}
+	}
+
+	if user.Role == "admin" && user.Active() {
+		totalAdmins, err := s.userRepo.GetTotalAdmins(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get total admin count: %w", err)
+		}
+		if totalAdmins <= 1 {
+			return ErrLastActiveAdmin
+		}
+	}
 	return nil
 }
 
-func NewUserService(userRepo user_repository.UserRepository, jwtUtil *utils.JWTUtil) UserService {
-	return &userServiceImpl{userRepo: userRepo, jwtUtil: jwtUtil}
+// requireAdminsManage returns denied unless the caller's role holds the
+// admins.manage permission. Route permissions such as users.update can be
+// granted to any role, so they must not be enough to create or alter admins.
+func (s *userServiceImpl) requireAdminsManage(ctx context.Context, scope *models.Scope, denied error) error {
+	allowed, err := s.permissions.HasPermission(ctx, scope.Role, models.PermAdminsManage)
+	if err != nil {
+		return fmt.Errorf("failed to check permission: %w", err)
+	}
+	if !allowed {
+		return denied
+	}
+	return nil
+}
+
+// NewUserService builds the user service. loginGuard may be nil where no
+// logins happen, such as in CLI commands; logins are then unthrottled.
+func NewUserService(userRepo user_repository.UserRepository, classRepo user_repository.ClassRepository, tokenService TokenService, permissions PermissionService, files storage.Storage, loginGuard *loginguard.Guard) UserService {
+	return &userServiceImpl{userRepo: userRepo, classRepo: classRepo, tokenService: tokenService, permissions: permissions, files: files, loginGuard: loginGuard}
 }
 
 func (s *userServiceImpl) CreateAdmin(ctx context.Context, user *models.User) error {
@@ -48,17 +208,38 @@ func (s *userServiceImpl) CreateAdmin(ctx context.Context, user *models.User) er
 	return s.CreateUser(ctx, user)
 }
 
-func (s *userServiceImpl) CreateUser(ctx context.Context, user *models.User) error {
-	if user.Email == "" {
-		return fmt.Errorf("email cannot be empty")
+func (s *userServiceImpl) CreateGuru(ctx context.Context, user *models.User) error {
+	user.Role = "guru"
+	return s.CreateUser(ctx, user)
+}
+
+// BootstrapAdmin creates the very first admin account. It refuses to run once
+// any admin exists, which makes the setup token effectively single-use.
+func (s *userServiceImpl) BootstrapAdmin(ctx context.Context, user *models.User) error {
+	if err := s.validateNewUser(ctx, user); err != nil {
+		return err
 	}
 
-	existingUser, err := s.userRepo.GetUserByEmail(ctx, user.Email)
-	if err != nil && err.Error() != fmt.Sprintf("user with email %s not found", user.Email) {
-		return fmt.Errorf("failed to check for existing user: %w", err)
+	hashedPassword, err := utils.HashPassword(user.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
 	}
-	if existingUser != nil {
-		return fmt.Errorf("user with email %s already exists", user.Email)
+	user.Password = hashedPassword
+	user.Role = "admin"
+
+	created, err := s.userRepo.CreateFirstAdmin(ctx, user)
+	if err != nil {
+		return fmt.Errorf("service failed to bootstrap admin: %w", err)
+	}
+	if !created {
+		return ErrAdminAlreadyExists
+	}
+	return nil
+}
+
+func (s *userServiceImpl) CreateUser(ctx context.Context, user *models.User) error {
+	if err := s.validateNewUser(ctx, user); err != nil {
+		return err
 	}
 
 	hashedPassword, err := utils.HashPassword(user.Password)
@@ -74,95 +255,421 @@ func (s *userServiceImpl) CreateUser(ctx context.Context, user *models.User) err
 	return nil
 }
 
-func (s *userServiceImpl) Login(ctx context.Context, email, password string) (string, error) {
+func (s *userServiceImpl) validateNewUser(ctx context.Context, user *models.User) error {
+	if user.Email == "" {
+		return apperrors.Validation("email cannot be empty").WithField("email", "is required")
+	}
+	if user.Password == "" {
+		return apperrors.Validation("password cannot be empty").WithField("password", "is required")
+	}
+
+	existingUser, err := s.userRepo.GetUserByEmail(ctx, user.Email)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return fmt.Errorf("failed to check for existing user: %w", err)
+	}
+	if existingUser != nil {
+		return apperrors.Conflict("user with email %s already exists", user.Email)
+	}
+
+	if user.ClassID != nil {
+		class, err := s.lookupClass(ctx, *user.ClassID)
+		if err != nil {
+			return err
+		}
+		user.ClassName = &class.Name
+	}
+	return nil
+}
+
+// lookupClass loads the class a user is being assigned to, reporting a
+// missing class as a validation error on class_id.
+func (s *userServiceImpl) lookupClass(ctx context.Context, classID int64) (*models.Class, error) {
+	class, err := s.classRepo.GetClassByID(ctx, classID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.Validation("class with ID %d does not exist", classID).WithField("class_id", "does not exist")
+		}
+		return nil, fmt.Errorf("failed to get class: %w", err)
+	}
+	return class, nil
+}
+
+// Login checks the credentials, throttled per client IP and per email by the
+// login guard. Unknown emails count as failures too, so the guard's answers
+// do not reveal which accounts exist.
+func (s *userServiceImpl) Login(ctx context.Context, email, password, clientIP string) (*dto.TokenResponse, error) {
+	if err := s.checkLoginGuard(ctx, clientIP, email); err != nil {
+		metrics.LoginAttempt(metrics.LoginBlocked)
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		if err.Error() == fmt.Sprintf("user with email %s not found", email) {
-			return "", fmt.Errorf("invalid credentials")
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, s.loginFailed(ctx, clientIP, email)
 		}
-		return "", fmt.Errorf("failed to retrieve user: %w", err)
+		return nil, fmt.Errorf("failed to retrieve user: %w", err)
+	}
+
+	if user.DeletedAt != nil || !utils.CheckPasswordHash(password, user.Password) {
+		return nil, s.loginFailed(ctx, clientIP, email)
+	}
+	if !user.IsActive {
+		metrics.LoginAttempt(metrics.LoginDeactivated)
+		return nil, ErrAccountDeactivated
 	}
 
-	if !utils.CheckPasswordHash(password, user.Password) {
-		return "", fmt.Errorf("invalid credentials")
+	if s.loginGuard != nil {
+		if err := s.loginGuard.Succeed(ctx, email); err != nil {
+			slog.ErrorContext(ctx, "Failed to reset login failures", "email", email, "error", err)
+		}
 	}
 
-	tokenString, err := s.jwtUtil.GenerateJWTToken(user)
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate authentication token: %w", err)
+		return nil, fmt.Errorf("failed to generate authentication token: %w", err)
+	}
+
+	metrics.LoginAttempt(metrics.LoginSucceeded)
+	return tokens, nil
+}
+
+// checkLoginGuard refuses attempts while the account is locked or the caller
+// has to back off. The guard fails open: if its store is unreachable, logins
+// still work, only unthrottled.
+func (s *userServiceImpl) checkLoginGuard(ctx context.Context, clientIP, email string) error {
+	if s.loginGuard == nil {
+		return nil
 	}
 
-	return tokenString, nil
+	block, err := s.loginGuard.Check(ctx, clientIP, email)
+	if err != nil {
+		slog.ErrorContext(ctx, "Login guard check failed, allowing attempt", "error", err)
+		return nil
+	}
+	if block == nil {
+		return nil
+	}
+	if block.Locked {
+		return ErrAccountLocked.WithRetryAfter(block.RetryAfter)
+	}
+	return ErrTooManyLoginAttempts.WithRetryAfter(block.RetryAfter)
+}
+
+// loginFailed records the failure and returns the error for the client.
+func (s *userServiceImpl) loginFailed(ctx context.Context, clientIP, email string) error {
+	metrics.LoginAttempt(metrics.LoginFailed)
+	if s.loginGuard == nil {
+		return ErrInvalidCredentials
+	}
+
+	locked, err := s.loginGuard.Fail(ctx, clientIP, email)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record failed login", "email", email, "error", err)
+	}
+	if locked {
+		slog.WarnContext(ctx, "Account locked after repeated failed logins", "email", email, "client_ip", clientIP)
+	}
+	return ErrInvalidCredentials
+}
+
+// UnlockUser lifts a login lockout of the user before it expires.
+func (s *userServiceImpl) UnlockUser(ctx context.Context, scope *models.Scope, id int64) error {
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user by ID %d: %w", id, err)
+	}
+	if err := checkUserInScope(scope, user); err != nil {
+		return err
+	}
+
+	if s.loginGuard == nil {
+		return nil
+	}
+	if err := s.loginGuard.Unlock(ctx, user.Email); err != nil {
+		return fmt.Errorf("service failed to unlock user with ID %d: %w", id, err)
+	}
+	return nil
 }
 
 func (s *userServiceImpl) GetUserByID(ctx context.Context, id int64) (*dto.UserResponse, error) {
 	user, err := s.userRepo.GetUserByID(ctx, id)
 	if err != nil {
-		if err.Error() == fmt.Sprintf("user with ID %d not found", id) {
-			return nil, nil
-		}
 		return nil, fmt.Errorf("failed to retrieve user by ID %d: %w", id, err)
 	}
 
-	var userClass string
-	if user.Class != nil {
-		userClass = *user.Class
+	return s.toUserResponse(user), nil
+}
+
+func (s *userServiceImpl) UpdateProfile(ctx context.Context, userID int64, req *dto.UpdateProfileRequest) (*dto.UserResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve user by ID %d: %w", userID, err)
 	}
 
-	var userBirthday string
-	if user.Birthday != nil {
-		userBirthday = user.Birthday.Format("2006-01-02")
+	if err := s.applyProfileChanges(user, req); err != nil {
+		return nil, err
 	}
 
-	var userProfileURL string
-	if user.ProfileURL != nil {
-		userProfileURL = *user.ProfileURL
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("service failed to update profile: %w", err)
+	}
+	return s.toUserResponse(user), nil
+}
+
+// ChangePassword verifies the current password, stores the new hash and
+// revokes every existing session. A fresh token pair is returned so the
+// caller stays signed in on the device that made the change.
+func (s *userServiceImpl) ChangePassword(ctx context.Context, userID int64, req *dto.ChangePasswordRequest) (*dto.TokenResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve user by ID %d: %w", userID, err)
+	}
+
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		return nil, ErrIncorrectPassword
+	}
+	if utils.CheckPasswordHash(req.NewPassword, user.Password) {
+		return nil, ErrPasswordUnchanged
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.Password = hashedPassword
+
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("service failed to change password: %w", err)
 	}
 
-	responseDTO := &dto.UserResponse{
-		ID:         user.ID,
-		Name:       user.Name,
-		Email:      user.Email,
-		Class:      userClass,
-		Birthday:   userBirthday,
-		Role:       user.Role,
-		ProfileURL: userProfileURL,
-		CreatedAt:  user.CreatedAt,
-		UpdatedAt:  user.UpdatedAt,
+	if err := s.tokenService.RevokeAllUserSessions(ctx, userID); err != nil {
+		return nil, fmt.Errorf("password was changed but existing sessions could not be revoked: %w", err)
 	}
 
-	return responseDTO, nil
+	tokens, err := s.tokenService.IssueTokenPair(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate authentication token: %w", err)
+	}
+	return tokens, nil
 }
 
-func (s *userServiceImpl) UpdateProfileURL(ctx context.Context, userID int64, profileURL string) error {
-	err := s.userRepo.UpdateProfileURL(ctx, userID, profileURL)
+// AdminUpdateUser lets an admin edit any account and a guru edit the
+// students of their own classes. Changing a role or editing an admin account
+// also requires admins.manage. Email and role changes are checked more
+// strictly and revoke the user's sessions, because both are baked into
+// issued tokens.
+func (s *userServiceImpl) AdminUpdateUser(ctx context.Context, scope *models.Scope, userID int64, req *dto.AdminUpdateUserRequest) (*dto.UserResponse, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("service failed to update profile URL: %w", err)
+		return nil, fmt.Errorf("failed to retrieve user by ID %d: %w", userID, err)
+	}
+
+	if err := checkUserInScope(scope, user); err != nil {
+		return nil, err
+	}
+	if user.Role == "admin" {
+		if err := s.requireAdminsManage(ctx, scope, ErrAdminAccountDenied); err != nil {
+			return nil, err
+		}
+	}
+	if req.Role != nil && *req.Role != user.Role {
+		if err := s.requireAdminsManage(ctx, scope, ErrRoleChangeDenied); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.applyProfileChanges(user, &req.UpdateProfileRequest); err != nil {
+		return nil, err
+	}
+
+	if req.ClassID != nil {
+		if *req.ClassID == 0 {
+			user.ClassID, user.ClassName = nil, nil
+		} else {
+			class, err := s.lookupClass(ctx, *req.ClassID)
+			if err != nil {
+				return nil, err
+			}
+			user.ClassID, user.ClassName = &class.ID, &class.Name
+		}
+	}
+
+	// A guru may move a student between their classes, not out of them.
+	if !scope.CanAccessClass(user.ClassID) {
+		return nil, ErrUserOutOfScope
+	}
+
+	credentialsChanged := false
+
+	if req.Email != nil {
+		email := strings.TrimSpace(*req.Email)
+		if email != user.Email {
+			if !strings.EqualFold(email, user.Email) {
+				existing, err := s.userRepo.GetExistingEmails(ctx, []string{email})
+				if err != nil {
+					return nil, fmt.Errorf("failed to check for existing user: %w", err)
+				}
+				if existing[strings.ToLower(email)] {
+					return nil, apperrors.Conflict("user with email %s already exists", email).WithField("email", "is already registered")
+				}
+			}
+			user.Email = email
+			credentialsChanged = true
+		}
+	}
+
+	if req.Role != nil && *req.Role != user.Role {
+		if userID == scope.UserID {
+			return nil, ErrOwnRoleChange
+		}
+		if user.Role == "admin" {
+			totalAdmins, err := s.userRepo.GetTotalAdmins(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get total admin count: %w", err)
+			}
+			if totalAdmins <= 1 {
+				return nil, ErrLastAdmin
+			}
+		}
+		user.Role = *req.Role
+		credentialsChanged = true
+	}
+
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("service failed to update user: %w", err)
+	}
+
+	if credentialsChanged {
+		if err := s.tokenService.RevokeAllUserSessions(ctx, userID); err != nil {
+			return nil, fmt.Errorf("user was updated but existing sessions could not be revoked: %w", err)
+		}
 	}
+
+	return s.toUserResponse(user), nil
+}
+
+// checkUserInScope limits a guru to the students of their own classes.
+func checkUserInScope(scope *models.Scope, user *models.User) error {
+	if scope.ClassRestricted() && (user.Role != "mahasiswa" || !scope.CanAccessClass(user.ClassID)) {
+		return ErrUserOutOfScope
+	}
+	return nil
+}
+
+func (s *userServiceImpl) applyProfileChanges(user *models.User, req *dto.UpdateProfileRequest) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return apperrors.Validation("name cannot be empty").WithField("name", "cannot be empty")
+		}
+		user.Name = name
+	}
+
+	if req.Birthday != nil {
+		if req.Birthday.After(time.Now()) {
+			return apperrors.Validation("birthday cannot be in the future").WithField("birthday", "cannot be in the future")
+		}
+		user.Birthday = req.Birthday
+	}
+
 	return nil
 }
 
-func (s *userServiceImpl) GetOverallStudentSummary(ctx context.Context) (*dto.StudentSummary, error) {
-	classCounts, err := s.userRepo.GetStudentCountsByClass(ctx)
+// UpdateProfileImage validates and re-encodes the uploaded image, stores it
+// together with its thumbnails and records the original's storage key as the
+// user's profile_url.
+func (s *userServiceImpl) UpdateProfileImage(ctx context.Context, userID int64, file io.Reader) (*dto.ProfileImage, error) {
+	processed, err := imaging.Process(file, profileImageLimits, profileImageSizes)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			return nil, apperrors.Validation("profile image must be a JPEG, PNG, GIF or WebP image").WithField("profile_image", "must be a JPEG, PNG, GIF or WebP image")
+		case errors.Is(err, imaging.ErrFileTooLarge):
+			return nil, apperrors.Validation("profile image must be at most %d MB", MaxProfileImageSize>>20).WithField("profile_image", "is too large")
+		case errors.Is(err, imaging.ErrTooManyPixels):
+			return nil, apperrors.Validation("profile image dimensions are too large").WithField("profile_image", "dimensions are too large")
+		}
+		return nil, fmt.Errorf("failed to process profile image: %w", err)
+	}
+
+	dir := fmt.Sprintf("%s/%s", profileImageDir, uuid.New().String())
+	key := fmt.Sprintf("%s/original%s", dir, processed.Original.Extension)
+
+	stored := []string{}
+	put := func(key string, encoded imaging.Encoded) error {
+		if err := s.files.Put(ctx, key, bytes.NewReader(encoded.Data), int64(len(encoded.Data)), encoded.ContentType); err != nil {
+			return err
+		}
+		stored = append(stored, key)
+		return nil
+	}
+
+	err = put(key, processed.Original)
+	for _, variant := range processed.Variants {
+		if err != nil {
+			break
+		}
+		variantKey, _ := profileImageVariantKey(key, variant.Size)
+		err = put(variantKey, variant.Encoded)
+	}
+	var previous *string
+	if err == nil {
+		previous, err = s.userRepo.UpdateProfileURL(ctx, userID, key)
+	}
+	if err != nil {
+		for _, storedKey := range stored {
+			if deleteErr := s.files.Delete(ctx, storedKey); deleteErr != nil {
+				err = errors.Join(err, deleteErr)
+			}
+		}
+		return nil, fmt.Errorf("service failed to update profile image: %w", err)
+	}
+
+	metrics.ProfileImageUploaded()
+
+	if previous != nil {
+		s.deleteProfileImage(ctx, *previous)
+	}
+
+	return s.profileImage(key), nil
+}
+
+// deleteProfileImage removes a replaced profile image and its
+// thumbnails. Failures are only logged: the image is no longer referenced,
+// so the periodic sweep will retry.
+func (s *userServiceImpl) deleteProfileImage(ctx context.Context, profileURL string) {
+	for _, key := range profileImageKeys(profileURL) {
+		if err := s.files.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "Failed to delete profile image", "key", key, "error", err)
+		}
+	}
+}
+
+// GetOverallStudentSummary counts the students within the caller's scope.
+func (s *userServiceImpl) GetOverallStudentSummary(ctx context.Context, scope *models.Scope) (*dto.StudentSummary, error) {
+	classCounts, err := s.userRepo.GetStudentCountsByClass(ctx, scope.ClassFilter())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get student counts from repository: %w", err)
 	}
 
 	totalStudents := 0
 	for _, count := range classCounts {
-		totalStudents += count
+		totalStudents += count.Total
 	}
 
 	summary := &dto.StudentSummary{
 		TotalStudents: totalStudents,
-		ClassCounts:   classCounts,
+		Classes:       classCounts,
 	}
 
 	return summary, nil
 }
 
-func (s *userServiceImpl) GetAdminSummary(ctx context.Context) (*dto.AdminSummary, error) {
-	admins, err := s.userRepo.GetAdmins(ctx)
+func (s *userServiceImpl) GetAdminSummary(ctx context.Context, query *dto.ListUsersQuery) (*dto.AdminSummary, error) {
+	query.ApplyDefaults()
+
+	admins, total, err := s.userRepo.ListUsers(ctx, listFilter("admin", query))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get admin users from repository: %w", err)
 	}
@@ -174,73 +681,136 @@ func (s *userServiceImpl) GetAdminSummary(ctx context.Context) (*dto.AdminSummar
 
 	adminResponses := make([]dto.UserResponse, len(admins))
 	for i, admin := range admins {
-		var adminClass string
-		if admin.Class != nil {
-			adminClass = *admin.Class
-		}
-		var adminBirthday string
-		if admin.Birthday != nil {
-			adminBirthday = admin.Birthday.Format("2006-01-02")
-		}
-		var adminProfileURL string
-		if admin.ProfileURL != nil {
-			adminProfileURL = *admin.ProfileURL
-		}
-
-		adminResponses[i] = dto.UserResponse{
-			ID:         admin.ID,
-			Name:       admin.Name,
-			Email:      admin.Email,
-			Class:      adminClass,
-			Birthday:   adminBirthday,
-			Role:       admin.Role,
-			ProfileURL: adminProfileURL,
-			CreatedAt:  admin.CreatedAt,
-			UpdatedAt:  admin.UpdatedAt,
-		}
+		adminResponses[i] = *s.toUserResponse(admin)
 	}
 
 	summary := &dto.AdminSummary{
 		TotalAdmins: totalAdmins,
 		Admins:      adminResponses,
+		Pagination:  dto.NewPaginationMeta(query.Page, query.Limit, total),
 	}
 
 	return summary, nil
 }
 
-func (s *userServiceImpl) GetMahasiswaUsers(ctx context.Context) ([]*dto.UserResponse, error) {
-	mahasiswaUsers, err := s.userRepo.GetMahasiswaUsers(ctx)
+// GetMahasiswaUsers lists the students within the caller's scope.
+func (s *userServiceImpl) GetMahasiswaUsers(ctx context.Context, scope *models.Scope, query *dto.ListUsersQuery) (*dto.UserListResponse, error) {
+	query.ApplyDefaults()
+
+	filter := listFilter("mahasiswa", query)
+	filter.ClassIDs = scope.ClassFilter()
+
+	mahasiswaUsers, total, err := s.userRepo.ListUsers(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get mahasiswa users from repository: %w", err)
 	}
 
 	mahasiswaResponses := make([]*dto.UserResponse, len(mahasiswaUsers))
 	for i, user := range mahasiswaUsers {
-		var userClass string
-		if user.Class != nil {
-			userClass = *user.Class
-		}
-		var userBirthday string
-		if user.Birthday != nil {
-			userBirthday = user.Birthday.Format("2006-01-02")
-		}
-		var userProfileURL string
-		if user.ProfileURL != nil {
-			userProfileURL = *user.ProfileURL
+		mahasiswaResponses[i] = s.toUserResponse(user)
+	}
+
+	return &dto.UserListResponse{
+		Data:       mahasiswaResponses,
+		Pagination: dto.NewPaginationMeta(query.Page, query.Limit, total),
+	}, nil
+}
+
+func listFilter(role string, query *dto.ListUsersQuery) user_repository.UserListFilter {
+	return user_repository.UserListFilter{
+		Status:  query.Status,
+		Role:    role,
+		ClassID: query.ClassID,
+		Search:  query.Search,
+		Sort:    query.Sort,
+		Order:   query.Order,
+		Limit:   query.Limit,
+		Offset:  query.Offset(),
+	}
+}
+
+func (s *userServiceImpl) toUserResponse(user *models.User) *dto.UserResponse {
+	var userClass string
+	if user.ClassName != nil {
+		userClass = *user.ClassName
+	}
+
+	var userBirthday string
+	if user.Birthday != nil {
+		userBirthday = user.Birthday.Format("2006-01-02")
+	}
+
+	var profileImage *dto.ProfileImage
+	if user.ProfileURL != nil {
+		profileImage = s.profileImage(*user.ProfileURL)
+	}
+
+	return &dto.UserResponse{
+		ID:           user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		ClassID:      user.ClassID,
+		Class:        userClass,
+		Birthday:     userBirthday,
+		Role:         user.Role,
+		ProfileImage: profileImage,
+		IsActive:     user.IsActive,
+		DeletedAt:    user.DeletedAt,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
+}
+
+// profileImage resolves a stored profile_url to the URLs of the image and
+// its thumbnails. Images uploaded before thumbnails were generated, and rows
+// still holding an absolute URL, use the original for every size.
+func (s *userServiceImpl) profileImage(key string) *dto.ProfileImage {
+	if key == "" {
+		return nil
+	}
+
+	absolute := strings.HasPrefix(key, "http://") || strings.HasPrefix(key, "https://")
+
+	image := &dto.ProfileImage{Original: key, Variants: make(map[string]string, len(profileImageSizes))}
+	if !absolute {
+		image.Original = s.files.URL(key)
+	}
+
+	for _, size := range profileImageSizes {
+		url := image.Original
+		if variantKey, ok := profileImageVariantKey(key, size); ok && !absolute {
+			url = s.files.URL(variantKey)
 		}
+		image.Variants[strconv.Itoa(size)] = url
+	}
+	return image
+}
 
-		mahasiswaResponses[i] = &dto.UserResponse{
-			ID:         user.ID,
-			Name:       user.Name,
-			Email:      user.Email,
-			Class:      userClass,
-			Birthday:   userBirthday,
-			Role:       user.Role,
-			ProfileURL: userProfileURL,
-			CreatedAt:  user.CreatedAt,
-			UpdatedAt:  user.UpdatedAt,
+// profileImageKeys lists the storage keys belonging to a profile_url value:
+// the original and, for the current layout, its thumbnails. Absolute URLs
+// are not stored by us and yield no keys.
+func profileImageKeys(profileURL string) []string {
+	if profileURL == "" || strings.HasPrefix(profileURL, "http://") || strings.HasPrefix(profileURL, "https://") {
+		return nil
+	}
+
+	keys := []string{profileURL}
+	for _, size := range profileImageSizes {
+		if variantKey, ok := profileImageVariantKey(profileURL, size); ok {
+			keys = append(keys, variantKey)
 		}
 	}
+	return keys
+}
 
-	return mahasiswaResponses, nil
+// profileImageVariantKey derives the thumbnail key from the key of an
+// original stored as <dir>/original.<ext>. It reports false for keys that
+// do not follow that layout.
+func profileImageVariantKey(key string, size int) (string, bool) {
+	dir, file := path.Split(key)
+	extension := path.Ext(file)
+	if strings.TrimSuffix(file, extension) != "original" {
+		return "", false
+	}
+	return fmt.Sprintf("%s%d%s", dir, size, extension), true
 }
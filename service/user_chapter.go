@@ -1,11 +1,15 @@
 package service
 
 import (
+	"be-education/apperrors"
 	"be-education/dto"
+	"be-education/export"
 	"be-education/models"
 	"be-education/repository"
 	"context"
 	"fmt"
+	"io"
+	"math"
 	"sort"
 )
 
@@ -13,20 +17,29 @@ type UserChapterService interface {
 	CreateUserChapter(ctx context.Context, userChapter *models.UserChapter) error
 	GetUserQuizScoresByUserID(ctx context.Context, userID int64) ([]*dto.UserChapterQuizScoreResponse, error)
 	CheckUserChapterCompleted(ctx context.Context, userID int64, chapterID int) (bool, error)
-	GetAllUsersChapterScoresSummary(ctx context.Context) (*dto.UserChapterScoresSummary, error)
+	GetAllUsersChapterScoresSummary(ctx context.Context, scope *models.Scope, classID int64) (*dto.UserChapterScoresSummary, error)
+	ExportGradebook(ctx context.Context, scope *models.Scope, w io.Writer, format string, class *models.Class) error
 }
 
 type userChapterServiceImpl struct {
 	userChapterRepo repository.UserChapterRepository
+	chapterRepo     repository.ChapterRepository
 }
 
-func NewUserChapterService(userChapterRepo repository.UserChapterRepository) UserChapterService {
-	return &userChapterServiceImpl{userChapterRepo: userChapterRepo}
+func NewUserChapterService(userChapterRepo repository.UserChapterRepository, chapterRepo repository.ChapterRepository) UserChapterService {
+	return &userChapterServiceImpl{userChapterRepo: userChapterRepo, chapterRepo: chapterRepo}
 }
 
 func (s *userChapterServiceImpl) CreateUserChapter(ctx context.Context, userChapter *models.UserChapter) error {
+	exists, err := s.chapterRepo.ChapterExists(ctx, userChapter.ChapterID)
+	if err != nil {
+		return fmt.Errorf("service failed to check chapter: %w", err)
+	}
+	if !exists {
+		return apperrors.Validation("chapter with ID %d does not exist", userChapter.ChapterID).WithField("chapter_id", "does not exist")
+	}
 
-	err := s.userChapterRepo.CreateUserChapter(ctx, userChapter)
+	err = s.userChapterRepo.CreateUserChapter(ctx, userChapter)
 	if err != nil {
 		return fmt.Errorf("service failed to create user chapter: %w", err)
 	}
@@ -49,8 +62,20 @@ func (s *userChapterServiceImpl) CheckUserChapterCompleted(ctx context.Context,
 	return completed, nil
 }
 
-func (s *userChapterServiceImpl) GetAllUsersChapterScoresSummary(ctx context.Context) (*dto.UserChapterScoresSummary, error) {
-	rawScores, err := s.userChapterRepo.GetAllUsersWithAllChapterScores(ctx)
+// GetAllUsersChapterScoresSummary returns the scores of the students within
+// the caller's scope, optionally narrowed to one class.
+func (s *userChapterServiceImpl) GetAllUsersChapterScoresSummary(ctx context.Context, scope *models.Scope, classID int64) (*dto.UserChapterScoresSummary, error) {
+	classIDs, err := gradebookClassFilter(scope, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters, err := s.chapterRepo.GetAllChapters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chapters from repository: %w", err)
+	}
+
+	rawScores, err := s.userChapterRepo.GetAllUsersWithAllChapterScores(ctx, classIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get raw chapter scores from repository: %w", err)
 	}
@@ -62,15 +87,18 @@ func (s *userChapterServiceImpl) GetAllUsersChapterScoresSummary(ctx context.Con
 			userScoresMap[rs.UserID] = dto.UserScoreEntry{
 				ID:            rs.UserID,
 				Name:          rs.UserName,
+				ClassID:       rs.ClassID,
 				Class:         rs.UserClass,
 				ChapterScores: make(map[string]float64),
 			}
 		}
 		userEntry := userScoresMap[rs.UserID]
 
-		if rs.ChapterID > 0 {
+		// Chapters without a quiz score are left out, so averages only
+		// count scored chapters, as in ExportGradebook.
+		if rs.ChapterID > 0 && rs.Score != nil {
 			chapterKey := fmt.Sprintf("C%d", rs.ChapterID) // Format as "C1", "C2", etc.
-			userEntry.ChapterScores[chapterKey] = rs.Score
+			userEntry.ChapterScores[chapterKey] = *rs.Score
 		}
 		userScoresMap[rs.UserID] = userEntry
 	}
@@ -84,9 +112,262 @@ func (s *userChapterServiceImpl) GetAllUsersChapterScoresSummary(ctx context.Con
 		return usersScores[i].ID < usersScores[j].ID
 	})
 
+	gradebookChapters := make([]dto.GradebookChapter, len(chapters))
+	for i, chapter := range chapters {
+		gradebookChapters[i] = dto.GradebookChapter{
+			Key:      fmt.Sprintf("C%d", chapter.ID),
+			ID:       chapter.ID,
+			Name:     chapter.Name,
+			Position: chapter.Position,
+		}
+	}
+
 	summary := &dto.UserChapterScoresSummary{
+		Chapters:    gradebookChapters,
+		Classes:     classScoreSummaries(gradebookChapters, usersScores),
 		UsersScores: usersScores,
 	}
 
 	return summary, nil
 }
+
+// classScoreSummaries groups the students by class ID and averages each
+// chapter over the students of the class that have a score for it. Classes
+// are ordered by name, with students without a class last.
+func classScoreSummaries(chapters []dto.GradebookChapter, usersScores []dto.UserScoreEntry) []dto.ClassScoreSummary {
+	type classGroup struct {
+		id    *int64
+		name  string
+		stats *gradebookClassStats
+	}
+
+	groups := make(map[int64]*classGroup)
+	var order []*classGroup
+	for _, entry := range usersScores {
+		var key int64
+		if entry.ClassID != nil {
+			key = *entry.ClassID
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &classGroup{id: entry.ClassID, name: entry.Class, stats: newGradebookClassStats(len(chapters))}
+			groups[key] = group
+			order = append(order, group)
+		}
+
+		student := &export.StudentRow{Scores: make([]*float64, len(chapters))}
+		for i, chapter := range chapters {
+			if score, ok := entry.ChapterScores[chapter.Key]; ok {
+				student.Scores[i] = &score
+			}
+		}
+		student.Average = averageScores(student.Scores)
+		group.stats.add(student)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if (order[i].id == nil) != (order[j].id == nil) {
+			return order[j].id == nil
+		}
+		return order[i].name < order[j].name
+	})
+
+	summaries := make([]dto.ClassScoreSummary, 0, len(order))
+	for _, group := range order {
+		classSummary := group.stats.summary(group.name)
+		averages := make(map[string]float64, len(chapters))
+		for i, average := range classSummary.Averages {
+			if average != nil {
+				averages[chapters[i].Key] = *average
+			}
+		}
+		summaries = append(summaries, dto.ClassScoreSummary{
+			ClassID:         group.id,
+			ClassName:       group.name,
+			Students:        classSummary.Students,
+			ChapterAverages: averages,
+			Average:         classSummary.Average,
+		})
+	}
+	return summaries
+}
+
+// ExportGradebook writes the gradebook in the given export format. Rows are
+// read from a database cursor and handed to the writer one student at a
+// time; each class is closed with a footer of per-chapter class averages.
+// A nil class exports every student within the caller's scope.
+func (s *userChapterServiceImpl) ExportGradebook(ctx context.Context, scope *models.Scope, w io.Writer, format string, class *models.Class) error {
+	title := "Gradebook"
+	var classID int64
+	if class != nil {
+		title = fmt.Sprintf("Gradebook - %s", class.Name)
+		classID = class.ID
+	}
+
+	classIDs, err := gradebookClassFilter(scope, classID)
+	if err != nil {
+		return err
+	}
+
+	chapters, err := s.chapterRepo.GetAllChapters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chapters from repository: %w", err)
+	}
+
+	writer, err := export.NewGradebookWriter(format, w, title)
+	if err != nil {
+		return apperrors.Validation("%s", err.Error())
+	}
+
+	columns := make([]export.Column, len(chapters))
+	columnIndex := make(map[int64]int, len(chapters))
+	for i, chapter := range chapters {
+		columns[i] = export.Column{ChapterID: chapter.ID, Name: chapter.Name}
+		columnIndex[chapter.ID] = i
+	}
+	if err := writer.WriteHeader(columns); err != nil {
+		return fmt.Errorf("failed to write gradebook header: %w", err)
+	}
+
+	var (
+		student        *export.StudentRow
+		studentID      int64
+		currentClass   string
+		currentClassID *int64
+		classStats     *gradebookClassStats
+	)
+
+	flushStudent := func() error {
+		if student == nil {
+			return nil
+		}
+		student.Average = averageScores(student.Scores)
+		classStats.add(student)
+		err := writer.WriteStudent(*student)
+		student = nil
+		return err
+	}
+	flushClass := func() error {
+		if classStats == nil || classStats.students == 0 {
+			return nil
+		}
+		return writer.WriteClassSummary(classStats.summary(currentClass))
+	}
+
+	err = s.userChapterRepo.StreamGradebookScores(ctx, classIDs, func(row *dto.GradebookScore) error {
+		if student == nil || row.UserID != studentID {
+			if err := flushStudent(); err != nil {
+				return err
+			}
+			if classStats == nil || !sameClass(row.ClassID, currentClassID) {
+				if err := flushClass(); err != nil {
+					return err
+				}
+				currentClass, currentClassID = row.UserClass, row.ClassID
+				classStats = newGradebookClassStats(len(columns))
+			}
+			studentID = row.UserID
+			student = &export.StudentRow{Name: row.UserName, Class: row.UserClass, Scores: make([]*float64, len(columns))}
+		}
+
+		if i, ok := columnIndex[row.ChapterID]; ok && row.Score != nil {
+			student.Scores[i] = row.Score
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export gradebook: %w", err)
+	}
+
+	if err := flushStudent(); err != nil {
+		return fmt.Errorf("failed to export gradebook: %w", err)
+	}
+	if err := flushClass(); err != nil {
+		return fmt.Errorf("failed to export gradebook: %w", err)
+	}
+	return writer.Close()
+}
+
+// gradebookClassFilter turns the requested class (0 for all) into the class
+// filter of the gradebook queries, refusing classes outside the scope.
+func gradebookClassFilter(scope *models.Scope, classID int64) ([]int64, error) {
+	if classID == 0 {
+		return scope.ClassFilter(), nil
+	}
+	if !scope.CanAccessClass(&classID) {
+		return nil, ErrClassOutOfScope
+	}
+	return []int64{classID}, nil
+}
+
+func sameClass(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// gradebookClassStats accumulates per-chapter sums for a class footer.
+type gradebookClassStats struct {
+	students   int
+	sums       []float64
+	counts     []int
+	averageSum float64
+	averageN   int
+}
+
+func newGradebookClassStats(columns int) *gradebookClassStats {
+	return &gradebookClassStats{sums: make([]float64, columns), counts: make([]int, columns)}
+}
+
+func (c *gradebookClassStats) add(student *export.StudentRow) {
+	c.students++
+	for i, score := range student.Scores {
+		if score != nil {
+			c.sums[i] += *score
+			c.counts[i]++
+		}
+	}
+	if student.Average != nil {
+		c.averageSum += *student.Average
+		c.averageN++
+	}
+}
+
+func (c *gradebookClassStats) summary(class string) export.ClassSummary {
+	averages := make([]*float64, len(c.sums))
+	for i := range c.sums {
+		if c.counts[i] > 0 {
+			averages[i] = roundedScore(c.sums[i] / float64(c.counts[i]))
+		}
+	}
+
+	var average *float64
+	if c.averageN > 0 {
+		average = roundedScore(c.averageSum / float64(c.averageN))
+	}
+
+	return export.ClassSummary{Class: class, Students: c.students, Averages: averages, Average: average}
+}
+
+// averageScores averages the chapters the student has a score for; it is nil
+// when the student has none.
+func averageScores(scores []*float64) *float64 {
+	var sum float64
+	var n int
+	for _, score := range scores {
+		if score != nil {
+			sum += *score
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	return roundedScore(sum / float64(n))
+}
+
+func roundedScore(value float64) *float64 {
+	rounded := math.Round(value*100) / 100
+	return &rounded
+}
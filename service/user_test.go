@@ -0,0 +1,187 @@
+package service
+
+import (
+	"be-education/apperrors"
+	"be-education/dto"
+	"be-education/models"
+	"be-education/repository"
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeUserRepo keeps users in memory. Methods the tests do not reach fall
+// through to the nil embedded interface and panic.
+type fakeUserRepo struct {
+	repository.UserRepository
+	users   map[int64]*models.User
+	updated bool
+}
+
+func (r *fakeUserRepo) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, apperrors.NotFound("user with ID %d not found", id)
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) UpdateUser(ctx context.Context, user *models.User) error {
+	r.users[user.ID] = user
+	r.updated = true
+	return nil
+}
+
+func (r *fakeUserRepo) DeleteUser(ctx context.Context, id int64) error {
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepo) GetTotalAdmins(ctx context.Context) (int, error) {
+	total := 0
+	for _, user := range r.users {
+		if user.Role == "admin" {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *fakeUserRepo) GetExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+type fakePermissionService struct {
+	PermissionService
+	grants map[string][]string
+}
+
+func (s *fakePermissionService) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	for _, granted := range s.grants[role] {
+		if granted == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeTokenService struct {
+	TokenService
+}
+
+func (s *fakeTokenService) RevokeAllUserSessions(ctx context.Context, userID int64) error {
+	return nil
+}
+
+func newTestUserService() (*userServiceImpl, *fakeUserRepo) {
+	classID := int64(1)
+	repo := &fakeUserRepo{users: map[int64]*models.User{
+		1: {ID: 1, Name: "Admin", Email: "admin@example.com", Role: "admin", IsActive: true},
+		2: {ID: 2, Name: "Second Admin", Email: "admin2@example.com", Role: "admin", IsActive: true},
+		3: {ID: 3, Name: "Student", Email: "student@example.com", Role: "mahasiswa", ClassID: &classID, IsActive: true},
+	}}
+	// The mahasiswa role was granted users.update and users.delete, but not
+	// admins.manage.
+	permissions := &fakePermissionService{grants: map[string][]string{
+		"admin":     {models.PermUsersUpdate, models.PermUsersDelete, models.PermAdminsManage},
+		"guru":      {models.PermUsersUpdate},
+		"mahasiswa": {models.PermUsersUpdate, models.PermUsersDelete},
+	}}
+	return &userServiceImpl{userRepo: repo, tokenService: &fakeTokenService{}, permissions: permissions}, repo
+}
+
+func TestAdminUpdateUserRequiresAdminsManage(t *testing.T) {
+	admin := "admin"
+	newName := "Renamed"
+	newEmail := "taken-over@example.com"
+
+	tests := []struct {
+		name    string
+		scope   models.Scope
+		userID  int64
+		req     dto.AdminUpdateUserRequest
+		wantErr error
+	}{
+		{
+			name:    "users.update without admins.manage cannot promote to admin",
+			scope:   models.Scope{UserID: 3, Role: "mahasiswa"},
+			userID:  3,
+			req:     dto.AdminUpdateUserRequest{Role: &admin},
+			wantErr: ErrRoleChangeDenied,
+		},
+		{
+			name:    "users.update without admins.manage cannot edit an admin",
+			scope:   models.Scope{UserID: 3, Role: "mahasiswa"},
+			userID:  1,
+			req:     dto.AdminUpdateUserRequest{Email: &newEmail},
+			wantErr: ErrAdminAccountDenied,
+		},
+		{
+			name:   "users.update without admins.manage can edit a student",
+			scope:  models.Scope{UserID: 3, Role: "mahasiswa"},
+			userID: 3,
+			req:    dto.AdminUpdateUserRequest{UpdateProfileRequest: dto.UpdateProfileRequest{Name: &newName}},
+		},
+		{
+			name:    "guru cannot change the role of their student",
+			scope:   models.Scope{UserID: 4, Role: "guru", ClassIDs: []int64{1}},
+			userID:  3,
+			req:     dto.AdminUpdateUserRequest{Role: &admin},
+			wantErr: ErrRoleChangeDenied,
+		},
+		{
+			name:   "admins.manage can promote to admin",
+			scope:  models.Scope{UserID: 1, Role: "admin"},
+			userID: 3,
+			req:    dto.AdminUpdateUserRequest{Role: &admin},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestUserService()
+			before := *repo.users[tt.userID]
+
+			got, err := s.AdminUpdateUser(context.Background(), &tt.scope, tt.userID, &tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("AdminUpdateUser error = %v, want %v", err, tt.wantErr)
+				}
+				if repo.updated || repo.users[tt.userID].Role != before.Role || repo.users[tt.userID].Email != before.Email {
+					t.Errorf("user was changed despite the error: %+v", repo.users[tt.userID])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AdminUpdateUser: %v", err)
+			}
+			if tt.req.Role != nil && got.Role != *tt.req.Role {
+				t.Errorf("role = %q, want %q", got.Role, *tt.req.Role)
+			}
+		})
+	}
+}
+
+func TestDeleteUserRequiresAdminsManageForAdmins(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   models.Scope
+		wantErr error
+	}{
+		{"users.delete without admins.manage", models.Scope{UserID: 3, Role: "mahasiswa"}, ErrAdminAccountDenied},
+		{"admins.manage", models.Scope{UserID: 1, Role: "admin"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestUserService()
+
+			err := s.DeleteUser(context.Background(), &tt.scope, 2)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteUser error = %v, want %v", err, tt.wantErr)
+			}
+			if _, exists := repo.users[2]; exists != (tt.wantErr != nil) {
+				t.Errorf("admin still exists = %v, want %v", exists, tt.wantErr != nil)
+			}
+		})
+	}
+}
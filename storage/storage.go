@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"be-education/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// Object describes a stored file.
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// Storage keeps uploaded files under slash-separated keys such as
+// "profile_images/<uuid>.png". Implementations must be safe for concurrent
+// use.
+type Storage interface {
+	// Put stores r under key, replacing any existing object. size may be -1
+	// when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object for reading; the caller must close it. It returns
+	// ErrNotFound when the key does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete removes the object. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL clients use to download the object.
+	URL(key string) string
+	// List calls fn for every object whose key starts with prefix.
+	List(ctx context.Context, prefix string, fn func(*Object) error) error
+}
+
+// New returns the Storage selected by cfg.Storage.Driver ("local" or "s3").
+func New(cfg *config.Config) (Storage, error) {
+	publicURL := strings.TrimSuffix(cfg.Server.BaseURL, "/") + "/uploads"
+
+	switch cfg.Storage.Driver {
+	case "local", "":
+		return NewLocalStorage(cfg.Storage.LocalDir, publicURL), nil
+	case "s3":
+		if cfg.Storage.S3.PublicURL != "" {
+			publicURL = cfg.Storage.S3.PublicURL
+		}
+		return NewS3Storage(cfg.Storage.S3, publicURL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// CleanKey normalizes key and rejects keys that would escape the storage
+// root, so a key taken from a request path can be used safely.
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+func objectURL(baseURL, key string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + key
+}
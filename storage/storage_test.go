@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "profile_images/a.png", want: "profile_images/a.png"},
+		{key: "/profile_images/a.png", want: "profile_images/a.png"},
+		{key: "a.png", want: "a.png"},
+		{key: "..a.png", want: "..a.png"},
+		{key: "", wantErr: true},
+		{key: "/", wantErr: true},
+		{key: ".", wantErr: true},
+		{key: "..", wantErr: true},
+		{key: "../secret", wantErr: true},
+		{key: "/../secret", wantErr: true},
+		{key: "profile_images/../../secret", wantErr: true},
+		{key: "profile_images/../a.png", wantErr: true},
+		{key: "profile_images/./a.png", wantErr: true},
+		{key: "profile_images//a.png", wantErr: true},
+		{key: "profile_images/", wantErr: true},
+		{key: "//etc/passwd", wantErr: true},
+		{key: `..\secret`, wantErr: true},
+		{key: `profile_images\a.png`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := CleanKey(tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("CleanKey(%q) = %q, %v; want ErrInvalidKey", tt.key, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("CleanKey(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+			}
+		})
+	}
+}
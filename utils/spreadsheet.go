@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrUnsupportedSpreadsheet = errors.New("unsupported spreadsheet format, expected .csv or .xlsx")
+
+// ReadSpreadsheet reads a CSV file or the first sheet of an XLSX workbook into
+// rows of cells, returned as written; callers decide which cells to trim. The
+// format is chosen from the file extension. XLSX
+// cells are returned raw, so dates come back as Excel serial numbers; use
+// ParseSpreadsheetDate to read them.
+func ReadSpreadsheet(r io.Reader, filename string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return readCSV(r)
+	case ".xlsx":
+		return readXLSX(r)
+	default:
+		return nil, ErrUnsupportedSpreadsheet
+	}
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+
+	// Spreadsheet apps in locales that use a decimal comma export CSV with
+	// semicolons, so sniff the delimiter from the header line.
+	header, _ := br.Peek(4096)
+	firstLine, _, _ := bytes.Cut(header, []byte("\n"))
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	return records, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX workbook has no sheets")
+	}
+
+	rows, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX sheet %q: %w", sheets[0], err)
+	}
+	return rows, nil
+}
+
+var spreadsheetDateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "2006/01/02"}
+
+// ParseSpreadsheetDate accepts ISO dates, day-first dates, and the serial
+// numbers Excel stores for date cells.
+func ParseSpreadsheetDate(value string) (time.Time, error) {
+	for _, layout := range spreadsheetDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+		t, err := excelize.ExcelDateToTime(serial, false)
+		if err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
@@ -18,16 +19,23 @@ type Claims struct {
 
 type JWTUtil struct {
 	secretKey []byte
+	accessTTL time.Duration
 }
 
-func NewJWTUtil(secretKey string) *JWTUtil {
+func NewJWTUtil(secretKey string, accessTTL time.Duration) *JWTUtil {
 	return &JWTUtil{
 		secretKey: []byte(secretKey),
+		accessTTL: accessTTL,
 	}
 }
 
-func (j *JWTUtil) GenerateJWTToken(user *models.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// AccessTTL is the lifetime of the access tokens it generates.
+func (j *JWTUtil) AccessTTL() time.Duration {
+	return j.accessTTL
+}
+
+func (j *JWTUtil) GenerateJWTToken(user *models.User) (string, *Claims, error) {
+	expirationTime := time.Now().Add(j.accessTTL)
 
 	claims := &Claims{
 		UserID: user.ID,
@@ -39,7 +47,7 @@ func (j *JWTUtil) GenerateJWTToken(user *models.User) (string, error) {
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "be-education-app",
 			Subject:   fmt.Sprintf("%d", user.ID),
-			ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+			ID:        uuid.New().String(),
 			Audience:  []string{"users"},
 		},
 	}
@@ -48,10 +56,10 @@ func (j *JWTUtil) GenerateJWTToken(user *models.User) (string, error) {
 
 	tokenString, err := token.SignedString(j.secretKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
 func (j *JWTUtil) ParseJWTToken(tokenString string) (*Claims, error) {
@@ -79,6 +87,7 @@ type ContextKey string
 
 const (
 	UserClaimsContextKey ContextKey = "userClaims"
+	ScopeContextKey      ContextKey = "scope"
 )
 
 func SetUserClaimsToContext(c *gin.Context, claims *Claims) {
@@ -93,3 +102,16 @@ func GetCurrentUserClaims(c *gin.Context) (*Claims, bool) {
 	claims, ok := val.(*Claims)
 	return claims, ok
 }
+
+func SetScopeToContext(c *gin.Context, scope *models.Scope) {
+	c.Set(string(ScopeContextKey), scope)
+}
+
+func GetCurrentScope(c *gin.Context) (*models.Scope, bool) {
+	val, ok := c.Get(string(ScopeContextKey))
+	if !ok {
+		return nil, false
+	}
+	scope, ok := val.(*models.Scope)
+	return scope, ok && scope != nil
+}